@@ -63,7 +63,6 @@ func (p *Process) Run(process func(ctx context.Context) (bool, error)) chan erro
 			if err != nil {
 				p.logger.Errorf("Process %s failed: %v", p.name, err)
 				p.done <- err
-				close(p.done)
 			}
 
 			if finished {
@@ -90,9 +89,14 @@ func (p *Process) Run(process func(ctx context.Context) (bool, error)) chan erro
 	return rc
 }
 
-func (p *Process) Stop(ctx context.Context) error {
+// Cancel cancels the context of the process without waiting for it
+func (p *Process) Cancel() {
 	p.logger.Infof("%s shutdown start", p.name)
 	p.cancel()
+}
+
+func (p *Process) Stop(ctx context.Context) error {
+	p.Cancel()
 	p.logger.Debugf("%s wait for shutdown done", p.name)
 
 	var err error
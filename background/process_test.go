@@ -241,6 +241,35 @@ func TestBackgroundProcessShutdownFailed(t *testing.T) {
 	assert.Equal(t, 110, val)
 }
 
+func TestBackgroundProcessFailed(t *testing.T) {
+	val := 0
+
+	logger := logger.NewZapLogger()
+
+	s := func(ctx context.Context) error {
+		val += 10
+
+		return nil
+	}
+
+	process := background.Process{}
+	process.Init(t.Name(), nil, s, logger)
+
+	p := func(ctx context.Context) (bool, error) {
+		return true, fmt.Errorf("Process failed")
+	}
+
+	<-process.Run(p)
+
+	err := <-process.Done()
+	assert.EqualError(t, err, "Process failed")
+
+	_, ok := <-process.Done()
+	assert.False(t, ok)
+
+	assert.Equal(t, 10, val)
+}
+
 func exec(ctx context.Context, timeout time.Duration, f func(...interface{}) (bool, error), param ...interface{}) (bool, error) {
 	timer := time.NewTimer(1 * time.Second)
 	defer timer.Stop()
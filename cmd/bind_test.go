@@ -1,7 +1,9 @@
 package cmd_test
 
 import (
+	"errors"
 	"os"
+	"reflect"
 	"testing"
 
 	lookup "github.com/mcuadros/go-lookup"
@@ -14,8 +16,8 @@ type TestConfig struct {
 	Integer        int          `env:"INTEGER" default:"123456"`
 	String         string       `env:"STRING" default:"test"`
 	Bool           bool         `env:"BOOL" default:"true"`
-	Options        TestOptions  `env:"OPTIONS"`
-	OptionsCopy    TestOptions  `env:"OPTIONS"`
+	Options        TestOptions  `env:"OPTIONS,shared"`
+	OptionsCopy    TestOptions  `env:"OPTIONS,shared"`
 	OptionsPointer *TestOptions `env:"OPTIONS_POINTER"`
 }
 
@@ -107,3 +109,81 @@ func TestDefaults(t *testing.T) {
 	err := rootCmd.Execute()
 	assert.NoError(t, err)
 }
+
+type DescribedConfig struct {
+	Host     string `env:"HOST" default:"localhost" description:"Server host"`
+	Port     int    `env:"PORT" default:"80" flag:"port" usage:"Server port"`
+	Password string `secret:"true"`
+}
+
+func TestBindings(t *testing.T) {
+	t.Parallel()
+
+	cfg := DescribedConfig{}
+
+	rootCmd := cmd.NewRootCmd(
+		&cobra.Command{Use: t.Name(),
+			Short: "Test program",
+		}, &cfg,
+	)
+
+	wanted := []*cmd.EnvBinding{
+		{
+			Key:         "host",
+			Env:         "HOST",
+			Type:        reflect.TypeOf(""),
+			Default:     "localhost",
+			Description: "Server host",
+		},
+		{
+			Key:         "port",
+			Env:         "PORT",
+			Type:        reflect.TypeOf(0),
+			Default:     "80",
+			Description: "Server port",
+		},
+		{
+			Key:    "password",
+			Env:    "PASSWORD",
+			Type:   reflect.TypeOf(""),
+			Secret: true,
+		},
+	}
+
+	assert.Equal(t, wanted, rootCmd.Bindings())
+
+	rootCmd.AutoBindEnv(&cfg)
+	assert.Equal(t, wanted, rootCmd.Bindings())
+}
+
+type ConflictConfig struct {
+	Host    string `env:"ADDRESS"`
+	Address string
+	Path    string      `env:"MY-PATH"`
+	Shared  TestOptions `env:"SHARED,shared"`
+	Copy    TestOptions `env:"SHARED"`
+}
+
+func TestBindingConflicts(t *testing.T) {
+	cfg := ConflictConfig{}
+
+	rootCmd := cmd.NewRootCmd(&cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {},
+	}, &cfg)
+
+	err := rootCmd.AutoBindEnv(&cfg)
+	if assert.Error(t, err) {
+		assert.Equal(t, &cmd.BindingError{Errors: []string{
+			"Invalid env var name MY-PATH for path",
+			"ADDRESS is bound to host, address",
+			"SHARED_PATH is bound to shared.path, copy.path",
+		}}, err)
+	}
+
+	rootCmd.SetArgs([]string{})
+	err = rootCmd.Execute()
+	var bindingErr *cmd.BindingError
+	assert.True(t, errors.As(err, &bindingErr))
+	assert.Equal(t, cmd.ExitConfig, cmd.ExitCode(err))
+}
@@ -0,0 +1,250 @@
+/*
+Copyright © 2021 Dirk Lembke <dirk@lembke.nz>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// EnvBinding describes an env var bound to a config key by AutoBindEnv
+type EnvBinding struct {
+	Key         string
+	Env         string
+	Type        reflect.Type
+	Default     string
+	Description string
+	Secret      bool
+	Shared      bool
+}
+
+// BindingError lists all invalid or conflicting env bindings
+type BindingError struct {
+	Errors []string
+}
+
+func (e *BindingError) Error() string {
+	lines := []string{"Invalid env bindings:"}
+	for _, err := range e.Errors {
+		lines = append(lines, "  - "+err)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+type indexedBinding struct {
+	key    string
+	env    string
+	elem   reflect.Type
+	naming EnvNaming
+}
+
+// bindings keeps the env and flag bindings of a command
+type bindings struct {
+	mutex   sync.RWMutex
+	env     []*EnvBinding
+	flags   map[string]*pflag.Flag
+	indexed []*indexedBinding
+}
+
+func newBindings() *bindings {
+	return &bindings{
+		flags: map[string]*pflag.Flag{},
+	}
+}
+
+// list returns the env bindings in the order of the config struct fields
+func (b *bindings) list() []*EnvBinding {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	return append([]*EnvBinding{}, b.env...)
+}
+
+// indexedList returns the slices of structs bound to indexed env vars
+func (b *bindings) indexedList() []*indexedBinding {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	return append([]*indexedBinding{}, b.indexed...)
+}
+
+func (b *bindings) registerEnv(f reflect.StructField, key string, env string, shared bool) {
+	key = strings.ToLower(key)
+
+	description := f.Tag.Get("description")
+	if description == "" {
+		description = f.Tag.Get("usage")
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, e := range b.env {
+		if e.Key == key && e.Env == env {
+			return
+		}
+	}
+
+	b.env = append(b.env, &EnvBinding{
+		Key:         key,
+		Env:         env,
+		Type:        f.Type,
+		Default:     f.Tag.Get("default"),
+		Description: description,
+		Secret:      f.Tag.Get("secret") == "true",
+		Shared:      shared,
+	})
+}
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// checkBindings returns an error for invalid env var names and for env vars
+// bound to more than one config key, unless all of these bindings are shared
+func checkBindings(bindings []*EnvBinding) error {
+	result := &BindingError{}
+
+	envs := []string{}
+	keys := map[string][]string{}
+	shared := map[string]bool{}
+
+	for _, b := range bindings {
+		if !envNamePattern.MatchString(b.Env) {
+			result.Errors = append(result.Errors, fmt.Sprintf("Invalid env var name %v for %v", b.Env, b.Key))
+		}
+
+		if _, ok := keys[b.Env]; !ok {
+			envs = append(envs, b.Env)
+			shared[b.Env] = true
+		}
+
+		keys[b.Env] = append(keys[b.Env], b.Key)
+		shared[b.Env] = shared[b.Env] && b.Shared
+	}
+
+	for _, env := range envs {
+		if len(keys[env]) > 1 && !shared[env] {
+			result.Errors = append(result.Errors, fmt.Sprintf("%v is bound to %v", env, strings.Join(keys[env], ", ")))
+		}
+	}
+
+	if len(result.Errors) > 0 {
+		return result
+	}
+
+	return nil
+}
+
+// registerIndexedEnv registers a slice of structs, which is read from
+// indexed env vars like SERVERS_0_HOST, SERVERS_1_HOST, ...
+func (b *bindings) registerIndexedEnv(f reflect.StructField, key string, env string, naming EnvNaming) {
+	elem := f.Type
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+
+	elem = elem.Elem()
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, i := range b.indexed {
+		if i.key == key && i.env == env {
+			return
+		}
+	}
+
+	b.indexed = append(b.indexed, &indexedBinding{
+		key:    key,
+		env:    env,
+		elem:   elem,
+		naming: naming,
+	})
+}
+
+// applyIndexedEnv sets the values of indexed env vars, they override
+// the values of the config files
+func (b *bindings) applyIndexedEnv(v *viper.Viper) {
+	b.mutex.RLock()
+	list := append([]*indexedBinding{}, b.indexed...)
+	b.mutex.RUnlock()
+
+	for _, i := range list {
+		if items := readIndexedEnv(i.elem, i.env, i.naming); len(items) > 0 {
+			v.Set(i.key, items)
+		}
+	}
+}
+
+func readIndexedEnv(elem reflect.Type, env string, naming EnvNaming) []interface{} {
+	items := []interface{}{}
+
+	for idx := 0; ; idx++ {
+		namer := newEnvNamer(fmt.Sprintf("%v_%d", env, idx), naming)
+		item := map[string]interface{}{}
+
+		walkEnv(elem, []string{}, []string{}, false, namer, func(f reflect.StructField, t reflect.Type, name string, env string, shared bool) {
+			if val, ok := os.LookupEnv(env); ok {
+				setValue(item, strings.Split(strings.ToLower(name), "."), val)
+			}
+		})
+
+		if len(item) == 0 {
+			return items
+		}
+
+		items = append(items, item)
+	}
+}
+
+func setValue(m map[string]interface{}, path []string, value interface{}) {
+	for _, p := range path[:len(path)-1] {
+		sub, ok := m[p].(map[string]interface{})
+		if !ok {
+			sub = map[string]interface{}{}
+			m[p] = sub
+		}
+
+		m = sub
+	}
+
+	m[path[len(path)-1]] = value
+}
+
+func (b *bindings) registerFlag(key string, flag *pflag.Flag) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.flags[strings.ToLower(key)] = flag
+}
+
+func (b *bindings) lookupFlag(key string) *pflag.Flag {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	return b.flags[key]
+}
@@ -0,0 +1,190 @@
+/*
+Copyright © 2021 Dirk Lembke <dirk@lembke.nz>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zauberhaus/42/generator"
+)
+
+// KeyChangeFunc is called with the key and the old and new value of a changed config subtree
+type KeyChangeFunc func(key string, old interface{}, new interface{})
+
+type keySubscriber struct {
+	pattern []string
+	f       KeyChangeFunc
+}
+
+// OnKeyChange registers a callback for changes of the config keys matching
+// the pattern after a reload, like log.level or database.*, where * matches
+// one key, the callback gets the values of the matched key and its subtree
+func (r *RootCommand) OnKeyChange(pattern string, f KeyChangeFunc) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	parts := strings.Split(strings.ToLower(pattern), ".")
+	if len(parts) > 1 && parts[len(parts)-1] == "*" {
+		parts = parts[:len(parts)-1]
+	}
+
+	r.keySubscribers = append(r.keySubscribers, keySubscriber{
+		pattern: parts,
+		f:       f,
+	})
+}
+
+// notifyKeys calls the key subscribers for the changes between old and new
+func (r *RootCommand) notifyKeys(old interface{}, new interface{}) {
+	r.mutex.RLock()
+	subscribers := append([]keySubscriber{}, r.keySubscribers...)
+	r.mutex.RUnlock()
+
+	if len(subscribers) == 0 {
+		return
+	}
+
+	changes := changedKeys(reflect.ValueOf(old), reflect.ValueOf(new), []string{})
+
+	for _, s := range subscribers {
+		matched := map[string]bool{}
+		keys := []string{}
+
+		for _, c := range changes {
+			if key, ok := matchKey(s.pattern, c); ok && !matched[key] {
+				matched[key] = true
+				keys = append(keys, key)
+			}
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			path := strings.Split(key, ".")
+			s.f(key, valueAt(reflect.ValueOf(old), path), valueAt(reflect.ValueOf(new), path))
+		}
+	}
+}
+
+// changedKeys returns the paths of the values, which differ between old and new
+func changedKeys(old reflect.Value, new reflect.Value, path []string) [][]string {
+	for old.Kind() == reflect.Pointer && new.Kind() == reflect.Pointer {
+		if old.IsNil() || new.IsNil() {
+			if old.IsNil() != new.IsNil() {
+				return [][]string{path}
+			}
+
+			return nil
+		}
+
+		old = old.Elem()
+		new = new.Elem()
+	}
+
+	if old.Kind() != reflect.Struct || isScalar(old.Type()) || old.Type() != new.Type() {
+		if reflect.DeepEqual(old.Interface(), new.Interface()) {
+			return nil
+		}
+
+		return [][]string{path}
+	}
+
+	result := [][]string{}
+
+	for i := 0; i < old.NumField(); i++ {
+		f := old.Type().Field(i)
+		if !f.IsExported() || generator.Excluded(f) {
+			continue
+		}
+
+		subPath := append(append([]string{}, path...), strings.ToLower(generator.KeyName(f)))
+		result = append(result, changedKeys(old.Field(i), new.Field(i), subPath)...)
+	}
+
+	return result
+}
+
+// matchKey returns the key of the subtree matched by the pattern, which
+// contains the changed path or is contained by it
+func matchKey(pattern []string, path []string) (string, bool) {
+	n := len(pattern)
+	if len(path) < n {
+		n = len(path)
+	}
+
+	for i := 0; i < n; i++ {
+		if pattern[i] != "*" && pattern[i] != path[i] {
+			return "", false
+		}
+	}
+
+	if len(path) >= len(pattern) {
+		return strings.Join(path[:len(pattern)], "."), true
+	}
+
+	for _, p := range pattern[n:] {
+		if p == "*" {
+			return "", false
+		}
+	}
+
+	return strings.Join(append(append([]string{}, path...), pattern[n:]...), "."), true
+}
+
+// valueAt returns the value of the config key path or nil, list elements
+// are selected by their index
+func valueAt(v reflect.Value, path []string) interface{} {
+	for _, key := range path {
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return nil
+			}
+
+			v = v.Elem()
+		}
+
+		if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= v.Len() {
+				return nil
+			}
+
+			v = v.Index(idx)
+			continue
+		}
+
+		if v.Kind() != reflect.Struct {
+			return nil
+		}
+
+		f, ok := fieldByKey(v.Type(), key)
+		if !ok {
+			return nil
+		}
+
+		v = v.FieldByIndex(f.Index)
+	}
+
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+
+	return v.Interface()
+}
@@ -0,0 +1,96 @@
+package cmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zauberhaus/42/cmd"
+)
+
+type ChangeConfig struct {
+	Name     string
+	Log      ChangeLog
+	Database *ChangeDatabase `yaml:"db"`
+}
+
+type ChangeLog struct {
+	Level string
+}
+
+type ChangeDatabase struct {
+	Host string
+	Port int
+}
+
+type keyChange struct {
+	key string
+	old interface{}
+	new interface{}
+}
+
+func TestKeyChange(t *testing.T) {
+	t.Parallel()
+
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	if !assert.NoError(t, os.WriteFile(filename, []byte("name: test\nlog:\n  level: info\ndb:\n  host: a\n  port: 1\n"), 0600)) {
+		return
+	}
+
+	mutex := sync.Mutex{}
+	changes := map[string][]keyChange{}
+	done := make(chan struct{})
+	once := sync.Once{}
+
+	record := func(pattern string) cmd.KeyChangeFunc {
+		return func(key string, old interface{}, new interface{}) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			changes[pattern] = append(changes[pattern], keyChange{key, old, new})
+		}
+	}
+
+	cfg := ChangeConfig{}
+	rootCmd := cmd.NewRootCmd(&cobra.Command{
+		Use: t.Name(),
+		Run: func(c *cobra.Command, args []string) {
+			assert.NoError(t, os.WriteFile(filename, []byte("name: test\nlog:\n  level: debug\ndb:\n  host: b\n  port: 1\n"), 0600))
+
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Error("Config change not received")
+			}
+		},
+	}, &cfg)
+
+	rootCmd.OnKeyChange("db.*", record("db.*"))
+	rootCmd.OnKeyChange("log.level", record("log.level"))
+	rootCmd.OnKeyChange("name", record("name"))
+	rootCmd.OnKeyChange("*.port", record("*.port"))
+	rootCmd.OnKeyChange("*", record("*"))
+	rootCmd.OnKeyChange("*", func(key string, old interface{}, new interface{}) {
+		if key == "log" {
+			once.Do(func() { close(done) })
+		}
+	})
+
+	rootCmd.SetArgs([]string{"--config", filename})
+	if !assert.NoError(t, rootCmd.Execute()) {
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	assert.Equal(t, map[string][]keyChange{
+		"db.*":      {{"db", &ChangeDatabase{Host: "a", Port: 1}, &ChangeDatabase{Host: "b", Port: 1}}},
+		"log.level": {{"log.level", "info", "debug"}},
+		"*":         {{"db", &ChangeDatabase{Host: "a", Port: 1}, &ChangeDatabase{Host: "b", Port: 1}}, {"log", ChangeLog{Level: "info"}, ChangeLog{Level: "debug"}}},
+	}, changes)
+}
@@ -0,0 +1,207 @@
+/*
+Copyright © 2021 Dirk Lembke <dirk@lembke.nz>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+	"github.com/zauberhaus/42/generator"
+	"gopkg.in/yaml.v3"
+)
+
+const skipValidation = "skip-validation"
+
+// ConfigCommand adds the config command to show the effective config
+// and to create a new config file
+func ConfigCommand(r *RootCommand) {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the configuration",
+	}
+
+	var format string
+	var sources bool
+
+	showCmd := &cobra.Command{
+		Use:   "show",
+		Short: "Show the effective configuration",
+		Args:  cobra.NoArgs,
+		Annotations: map[string]string{
+			skipValidation: "true",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := r.showConfig(format, sources)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprint(cmd.OutOrStdout(), string(data))
+			return nil
+		},
+	}
+
+	showCmd.Flags().StringVarP(&format, "output", "o", "yaml", "Output format (yaml, json, toml, hcl)")
+	showCmd.Flags().BoolVar(&sources, "sources", false, "Annotate every value with its source")
+
+	var force bool
+
+	initCmd := &cobra.Command{
+		Use:   "init [file]",
+		Short: "Write a config file with the default values",
+		Args:  cobra.MaximumNArgs(1),
+		Annotations: map[string]string{
+			skipValidation: "true",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file := ""
+			if len(args) > 0 {
+				file = args[0]
+			}
+
+			file, err := r.initConfig(file, force)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Config file written: %v\n", file)
+			return nil
+		},
+	}
+
+	initCmd.Flags().BoolVarP(&force, "force", "f", false, "Overwrite an existing file")
+
+	configCmd.AddCommand(showCmd, initCmd)
+	r.AddCommand(configCmd)
+}
+
+func (r *RootCommand) initConfig(file string, force bool) (string, error) {
+	if file == "" {
+		home, err := homedir.Dir()
+		if err != nil {
+			return "", err
+		}
+
+		file = filepath.Join(home, r.defaultConfigFile+".yaml")
+	}
+
+	config, err := newConfig(reflect.TypeOf(r.GetConfig()))
+	if err != nil {
+		return "", err
+	}
+
+	data, err := generator.Marshal(config, file)
+	if err != nil {
+		return "", err
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !force {
+		flags |= os.O_EXCL
+	}
+
+	f, err := os.OpenFile(file, flags, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return "", fmt.Errorf("Config file already exists, use --force to overwrite: %v", file)
+		}
+
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		return "", err
+	}
+
+	return file, nil
+}
+
+// ConfigSource returns where the value of a config key came from
+func (r *RootCommand) ConfigSource(key string) string {
+	key = strings.ToLower(key)
+
+	if flag := r.bindings.lookupFlag(key); flag != nil && flag.Changed {
+		return "flag --" + flag.Name
+	}
+
+	for _, env := range r.EnvBindings()[key] {
+		if val, ok := os.LookupEnv(env); ok && val != "" {
+			return "env " + env
+		}
+	}
+
+	if file := r.configFileOf(key); file != "" {
+		return "file " + file
+	}
+
+	if flag := r.bindings.lookupFlag(key); flag != nil {
+		return "flag default --" + flag.Name
+	}
+
+	return "default"
+}
+
+func (r *RootCommand) showConfig(format string, sources bool) ([]byte, error) {
+	if sources && format != "yaml" && format != "yml" {
+		return nil, fmt.Errorf("Sources are only supported for yaml output")
+	}
+
+	data, err := generator.Marshal(r.GetConfig(), "config."+format)
+	if err != nil {
+		return nil, err
+	}
+
+	if !sources {
+		return data, nil
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+
+	r.annotateSources(&node, []string{})
+
+	return yaml.Marshal(&node)
+}
+
+func (r *RootCommand) annotateSources(node *yaml.Node, path []string) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, n := range node.Content {
+			r.annotateSources(n, path)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			value := node.Content[i+1]
+			subPath := append(append([]string{}, path...), key.Value)
+
+			if value.Kind == yaml.MappingNode {
+				r.annotateSources(value, subPath)
+			} else {
+				value.LineComment = r.ConfigSource(strings.Join(subPath, "."))
+			}
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zauberhaus/42/cmd"
+)
+
+type ShowConfig struct {
+	Name    string `default:"default"`
+	Value   int
+	Speed   string `default:"fast"`
+	Target  string `flag:"target"`
+	Options ShowOptions
+}
+
+type ShowOptions struct {
+	Path string `env:"SHOW_PATH"`
+}
+
+func TestConfigShow(t *testing.T) {
+	t.Parallel()
+
+	cfg := ShowConfig{}
+
+	rootCmd := cmd.NewRootCmd(
+		&cobra.Command{Use: t.Name(),
+			Short: "Test program",
+		}, &cfg,
+	)
+
+	rootCmd.WithSubCommands(cmd.ConfigCommand)
+
+	rootCmd.SetArgs([]string{"config", "show", "-o", "json", "--target", "here"})
+
+	output := &bytes.Buffer{}
+	rootCmd.SetOut(output)
+
+	err := rootCmd.Execute()
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, `{"name":"default","value":0,"speed":"fast","target":"here","options":{"path":""}}`, output.String())
+	}
+}
+
+func TestConfigShowSources(t *testing.T) {
+	cfg := ShowConfig{}
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "config.yaml")
+
+	err := ioutil.WriteFile(filename, []byte("name: file\nvalue: 3\n"), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	rootCmd := cmd.NewRootCmd(
+		&cobra.Command{Use: t.Name(),
+			Short: "Test program",
+		}, &cfg,
+	)
+
+	rootCmd.WithSubCommands(cmd.ConfigCommand)
+
+	os.Setenv("VALUE", "7")
+	os.Setenv("SHOW_PATH", "/tmp")
+	defer os.Unsetenv("VALUE")
+	defer os.Unsetenv("SHOW_PATH")
+
+	rootCmd.SetArgs([]string{"config", "show", "--sources", "--config", filename, "--target", "here"})
+
+	output := &bytes.Buffer{}
+	rootCmd.SetOut(output)
+
+	err = rootCmd.Execute()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "name: file # file "+filename+"\n"+
+			"value: 7 # env VALUE\n"+
+			"speed: fast # default\n"+
+			"target: here # flag --target\n"+
+			"options:\n"+
+			"    path: /tmp # env SHOW_PATH\n", output.String())
+	}
+}
+
+func TestConfigShowSourcesFormat(t *testing.T) {
+	t.Parallel()
+
+	cfg := ShowConfig{}
+
+	rootCmd := cmd.NewRootCmd(
+		&cobra.Command{Use: t.Name(),
+			Short: "Test program",
+		}, &cfg,
+	)
+
+	rootCmd.WithSubCommands(cmd.ConfigCommand)
+
+	rootCmd.SetArgs([]string{"config", "show", "--sources", "-o", "json"})
+	rootCmd.SilenceErrors = true
+	rootCmd.SilenceUsage = true
+
+	err := rootCmd.Execute()
+	assert.EqualError(t, err, "Sources are only supported for yaml output")
+}
+
+func TestConfigInit(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "config.yaml")
+
+	run := func(args ...string) error {
+		cfg := ValidatedConfig{}
+
+		rootCmd := cmd.NewRootCmd(
+			&cobra.Command{Use: t.Name(),
+				Short: "Test program",
+			}, &cfg,
+		)
+
+		rootCmd.WithSubCommands(cmd.ConfigCommand)
+		rootCmd.SetArgs(append([]string{"config", "init", filename}, args...))
+		rootCmd.SetOut(&bytes.Buffer{})
+		rootCmd.SilenceErrors = true
+		rootCmd.SilenceUsage = true
+
+		return rootCmd.Execute()
+	}
+
+	err := run()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "host: \"\"\nport: 0\nmode: c\noptions:\n    retries: 1\n", string(data))
+	}
+
+	err = ioutil.WriteFile(filename, []byte("host: changed\n"), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	err = run()
+	assert.EqualError(t, err, "Config file already exists, use --force to overwrite: "+filename)
+
+	data, err = ioutil.ReadFile(filename)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "host: changed\n", string(data))
+	}
+
+	err = run("--force")
+	if assert.NoError(t, err) {
+		data, err = ioutil.ReadFile(filename)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "host: \"\"\nport: 0\nmode: c\noptions:\n    retries: 1\n", string(data))
+		}
+	}
+}
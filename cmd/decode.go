@@ -0,0 +1,224 @@
+/*
+Copyright © 2021 Dirk Lembke <dirk@lembke.nz>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+	"github.com/zauberhaus/42/generator"
+)
+
+func decodeHook() viper.DecoderConfigOption {
+	return viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.TextUnmarshallerHookFunc(),
+		stringToURLHook,
+		mapstructure.StringToTimeDurationHookFunc(),
+		stringToMapHook,
+		mapstructure.StringToSliceHookFunc(","),
+	))
+}
+
+// unmarshal decodes the viper settings into config, the keys are matched
+// with the mapstructure, yaml or json tag names of the fields and references
+// in secret fields are resolved
+func unmarshal(v *viper.Viper, config interface{}, execSecrets bool) error {
+	cfg := &mapstructure.DecoderConfig{
+		Result:           config,
+		WeaklyTypedInput: true,
+	}
+
+	decodeHook()(cfg)
+
+	decoder, err := mapstructure.NewDecoder(cfg)
+	if err != nil {
+		return err
+	}
+
+	data, err := fieldKeys(reflect.TypeOf(config), v.AllSettings(), execSecrets)
+	if err != nil {
+		return err
+	}
+
+	return decoder.Decode(data)
+}
+
+// fieldKeys replaces yaml and json tag names in data with the field names,
+// which are used by mapstructure for fields without mapstructure tag, and
+// resolves the references of secret fields
+func fieldKeys(t reflect.Type, data interface{}, execSecrets bool) (interface{}, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if m, ok := data.(map[interface{}]interface{}); ok {
+		d := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			d[fmt.Sprint(k)] = v
+		}
+
+		data = d
+	}
+
+	switch d := data.(type) {
+	case map[string]interface{}:
+		switch {
+		case t.Kind() == reflect.Struct && !isScalar(t):
+			result := make(map[string]interface{}, len(d))
+			for k, v := range d {
+				f, ok := fieldByKey(t, k)
+				if !ok {
+					if !excludedKey(t, k) {
+						result[k] = v
+					}
+
+					continue
+				}
+
+				val, err := fieldKeys(f.Type, v, execSecrets)
+				if err != nil {
+					return nil, err
+				}
+
+				if generator.IsSecret(f) {
+					if val, err = resolveSecretField(f.Type, val, execSecrets); err != nil {
+						return nil, fmt.Errorf("Resolve %v: %v", k, err)
+					}
+				}
+
+				if f.Tag.Get("mapstructure") == "" {
+					k = f.Name
+				}
+
+				result[k] = val
+			}
+
+			return result, nil
+		case t.Kind() == reflect.Map:
+			result := make(map[string]interface{}, len(d))
+			for k, v := range d {
+				val, err := fieldKeys(t.Elem(), v, execSecrets)
+				if err != nil {
+					return nil, err
+				}
+
+				result[k] = val
+			}
+
+			return result, nil
+		}
+	case []interface{}:
+		// hcl decodes a block into a list of objects
+		if t.Kind() == reflect.Struct && !isScalar(t) && len(d) == 1 {
+			return fieldKeys(t, d[0], execSecrets)
+		}
+
+		elem := t
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			elem = t.Elem()
+		}
+
+		result := make([]interface{}, len(d))
+		for i, v := range d {
+			val, err := fieldKeys(elem, v, execSecrets)
+			if err != nil {
+				return nil, err
+			}
+
+			result[i] = val
+		}
+
+		return result, nil
+	}
+
+	return data, nil
+}
+
+func fieldByKey(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !generator.Excluded(f) && strings.EqualFold(generator.KeyName(f), key) {
+			return f, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+// excludedKey returns true if mapstructure would decode the key into
+// a field, which isn't part of the config
+func excludedKey(t reflect.Type, key string) bool {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !generator.Excluded(f) {
+			continue
+		}
+
+		name, _, _ := strings.Cut(f.Tag.Get("mapstructure"), ",")
+		if name == "" {
+			name = f.Name
+		}
+
+		if strings.EqualFold(name, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func stringToURLHook(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+	if f.Kind() != reflect.String || t != urlType {
+		return data, nil
+	}
+
+	u, err := url.Parse(data.(string))
+	if err != nil {
+		return nil, err
+	}
+
+	return *u, nil
+}
+
+// stringToMapHook converts a list like "key1=value1,key2=value2" into a map
+func stringToMapHook(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+	if f.Kind() != reflect.String || t.Kind() != reflect.Map || t.Key().Kind() != reflect.String {
+		return data, nil
+	}
+
+	result := map[string]string{}
+
+	raw := data.(string)
+	if raw == "" {
+		return result, nil
+	}
+
+	for _, item := range strings.Split(raw, ",") {
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("Invalid map item, expected key=value: %v", item)
+		}
+
+		result[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+
+	return result, nil
+}
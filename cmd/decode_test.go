@@ -0,0 +1,110 @@
+package cmd_test
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zauberhaus/42/cmd"
+)
+
+type Priority int
+
+func (p *Priority) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*p = 1
+	case "high":
+		*p = 2
+	default:
+		return fmt.Errorf("Invalid priority: %s", text)
+	}
+
+	return nil
+}
+
+type ComplexConfig struct {
+	Complex ComplexOptions
+}
+
+type ComplexOptions struct {
+	Hosts    []string
+	Ports    []int
+	Labels   map[string]string
+	Timeout  time.Duration
+	IP       net.IP
+	Endpoint url.URL
+	Proxy    *url.URL
+	Priority Priority
+	Servers  []ComplexServer
+}
+
+type ComplexServer struct {
+	Host string `env:"HOST"`
+	Port int
+}
+
+func TestComplexEnv(t *testing.T) {
+	env := map[string]string{
+		"COMPLEX_HOSTS":          "a,b",
+		"COMPLEX_PORTS":          "1,2",
+		"COMPLEX_LABELS":         "app=test, tier=backend",
+		"COMPLEX_TIMEOUT":        "1m30s",
+		"COMPLEX_IP":             "10.0.0.1",
+		"COMPLEX_ENDPOINT":       "https://example.com/api",
+		"COMPLEX_PROXY":          "http://proxy:3128",
+		"COMPLEX_PRIORITY":       "high",
+		"COMPLEX_SERVERS_0_HOST": "first",
+		"COMPLEX_SERVERS_0_PORT": "8080",
+		"COMPLEX_SERVERS_1_HOST": "second",
+	}
+
+	for k, v := range env {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	cfg := ComplexConfig{}
+
+	rootCmd := cmd.NewRootCmd(
+		&cobra.Command{Use: t.Name(),
+			Short: "Test program",
+			Run: func(cmd *cobra.Command, args []string) {
+				c := cfg.Complex
+
+				assert.Equal(t, []string{"a", "b"}, c.Hosts)
+				assert.Equal(t, []int{1, 2}, c.Ports)
+				assert.Equal(t, map[string]string{"app": "test", "tier": "backend"}, c.Labels)
+				assert.Equal(t, 90*time.Second, c.Timeout)
+				assert.Equal(t, net.ParseIP("10.0.0.1"), c.IP)
+				assert.Equal(t, "https://example.com/api", c.Endpoint.String())
+				if assert.NotNil(t, c.Proxy) {
+					assert.Equal(t, "proxy:3128", c.Proxy.Host)
+				}
+				assert.Equal(t, Priority(2), c.Priority)
+				assert.Equal(t, []ComplexServer{{Host: "first", Port: 8080}, {Host: "second"}}, c.Servers)
+			},
+		}, &cfg,
+	)
+
+	assert.Equal(t, map[string][]string{
+		"complex.hosts":    {"COMPLEX_HOSTS"},
+		"complex.ports":    {"COMPLEX_PORTS"},
+		"complex.labels":   {"COMPLEX_LABELS"},
+		"complex.timeout":  {"COMPLEX_TIMEOUT"},
+		"complex.ip":       {"COMPLEX_IP"},
+		"complex.endpoint": {"COMPLEX_ENDPOINT"},
+		"complex.proxy":    {"COMPLEX_PROXY"},
+		"complex.priority": {"COMPLEX_PRIORITY"},
+	}, rootCmd.EnvBindings())
+
+	rootCmd.SetArgs([]string{})
+
+	err := rootCmd.Execute()
+	assert.NoError(t, err)
+}
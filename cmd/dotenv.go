@@ -0,0 +1,220 @@
+/*
+Copyright © 2021 Dirk Lembke <dirk@lembke.nz>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const dotenvFile = ".env"
+
+// loadEnvFiles sets the env vars of the files set by --env-file or of the
+// .env file next to the config, vars of the real environment are kept
+func (r *RootCommand) loadEnvFiles() error {
+	files := r.envFiles
+	if len(files) == 0 {
+		file, err := r.defaultEnvFile()
+		if err != nil {
+			return err
+		}
+
+		if file == "" {
+			return nil
+		}
+
+		files = []string{file}
+	}
+
+	values := map[string]string{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("Read env file: %v", err)
+		}
+
+		env, err := parseDotenv(string(data))
+		if err != nil {
+			return fmt.Errorf("Parse env file %v: %v", file, err)
+		}
+
+		for k, v := range env {
+			values[k] = v
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.dotenv == nil {
+		r.dotenv = map[string]bool{}
+	}
+
+	for k, v := range values {
+		if _, ok := os.LookupEnv(k); ok && !r.dotenv[k] {
+			continue
+		}
+
+		if err := os.Setenv(k, v); err != nil {
+			return err
+		}
+
+		r.dotenv[k] = true
+	}
+
+	return nil
+}
+
+// unloadEnvFiles removes the env vars set by loadEnvFiles
+func (r *RootCommand) unloadEnvFiles() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for k := range r.dotenv {
+		os.Unsetenv(k)
+	}
+
+	r.dotenv = nil
+}
+
+// defaultEnvFile returns the .env file in the dir of the last config file
+// or the working dir, if it exists
+func (r *RootCommand) defaultEnvFile() (string, error) {
+	files, err := r.configFiles()
+	if err != nil {
+		return "", err
+	}
+
+	dir := "."
+	if len(files) > 0 {
+		dir = filepath.Dir(files[len(files)-1])
+	}
+
+	file := filepath.Join(dir, dotenvFile)
+	if info, err := os.Stat(file); err != nil || info.IsDir() {
+		return "", nil
+	}
+
+	return file, nil
+}
+
+// parseDotenv parses lines like KEY=value, export KEY="value" or KEY='value',
+// double quoted values can contain escapes and span multiple lines
+func parseDotenv(data string) (map[string]string, error) {
+	result := map[string]string{}
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+
+	for i := 0; i < len(lines); i++ {
+		number := i + 1
+		line := strings.TrimSpace(lines[i])
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: missing '='", number)
+		}
+
+		key = strings.TrimSpace(key)
+		if !envNamePattern.MatchString(key) {
+			return nil, fmt.Errorf("line %d: invalid name %v", number, key)
+		}
+
+		value = strings.TrimSpace(value)
+
+		if value == "" || (value[0] != '"' && value[0] != '\'') {
+			if idx := strings.Index(value, " #"); idx >= 0 {
+				value = strings.TrimSpace(value[:idx])
+			}
+
+			result[key] = value
+			continue
+		}
+
+		quote := value[0]
+		value = value[1:]
+
+		end := closingQuote(value, quote)
+		for end < 0 && i+1 < len(lines) {
+			i++
+			value += "\n" + lines[i]
+			end = closingQuote(value, quote)
+		}
+
+		if end < 0 {
+			return nil, fmt.Errorf("line %d: unterminated quote", number)
+		}
+
+		if rest := strings.TrimSpace(value[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return nil, fmt.Errorf("line %d: unexpected characters after quote: %v", number, rest)
+		}
+
+		value = value[:end]
+		if quote == '"' {
+			value = unescape(value)
+		}
+
+		result[key] = value
+	}
+
+	return result, nil
+}
+
+func closingQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote == '"':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+
+	return -1
+}
+
+func unescape(s string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		i++
+
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String()
+}
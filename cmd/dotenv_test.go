@@ -0,0 +1,104 @@
+package cmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zauberhaus/42/cmd"
+)
+
+type DotenvConfig struct {
+	Host    string `env:"DOTENV_HOST"`
+	Port    int    `env:"DOTENV_PORT"`
+	Name    string `env:"DOTENV_NAME"`
+	Message string `env:"DOTENV_MESSAGE"`
+	Raw     string `env:"DOTENV_RAW"`
+	Token   string `env:"DOTENV_TOKEN"`
+}
+
+const dotenvContent = `# local settings
+DOTENV_HOST=localhost # comment
+export DOTENV_PORT=8080
+DOTENV_NAME="it's \"me\""
+DOTENV_MESSAGE="first
+second\tline"
+DOTENV_RAW='no $escape\n'
+DOTENV_TOKEN=from-file
+`
+
+func TestDotenv(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.yaml")
+
+	if !assert.NoError(t, os.WriteFile(config, []byte("host: file\n"), 0600)) {
+		return
+	}
+
+	if !assert.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte(dotenvContent), 0600)) {
+		return
+	}
+
+	os.Setenv("DOTENV_TOKEN", "from-env")
+
+	defer func() {
+		for _, k := range []string{"DOTENV_HOST", "DOTENV_PORT", "DOTENV_NAME", "DOTENV_MESSAGE", "DOTENV_RAW", "DOTENV_TOKEN"} {
+			os.Unsetenv(k)
+		}
+	}()
+
+	cfg := DotenvConfig{}
+	rootCmd := cmd.NewRootCmd(&cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {},
+	}, &cfg)
+
+	rootCmd.SetArgs([]string{"--config", config})
+
+	if assert.NoError(t, rootCmd.Execute()) {
+		assert.Equal(t, DotenvConfig{
+			Host:    "localhost",
+			Port:    8080,
+			Name:    `it's "me"`,
+			Message: "first\nsecond\tline",
+			Raw:     `no $escape\n`,
+			Token:   "from-env",
+		}, cfg)
+	}
+
+	_, ok := os.LookupEnv("DOTENV_HOST")
+	assert.False(t, ok)
+	assert.Equal(t, "from-env", os.Getenv("DOTENV_TOKEN"))
+}
+
+func TestDotenvFiles(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.env")
+	second := filepath.Join(dir, "second.env")
+
+	assert.NoError(t, os.WriteFile(first, []byte("DOTENV_HOST=first\nDOTENV_PORT=1\n"), 0600))
+	assert.NoError(t, os.WriteFile(second, []byte("DOTENV_PORT=2\n"), 0600))
+
+	defer os.Unsetenv("DOTENV_HOST")
+	defer os.Unsetenv("DOTENV_PORT")
+
+	cfg := DotenvConfig{}
+	rootCmd := cmd.NewRootCmd(&cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {},
+	}, &cfg)
+
+	rootCmd.SetArgs([]string{"--env-file", first, "--env-file", second})
+
+	if assert.NoError(t, rootCmd.Execute()) {
+		assert.Equal(t, "first", cfg.Host)
+		assert.Equal(t, 2, cfg.Port)
+	}
+
+	for _, k := range []string{"DOTENV_HOST", "DOTENV_PORT"} {
+		_, ok := os.LookupEnv(k)
+		assert.False(t, ok, k)
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright © 2021 Dirk Lembke <dirk@lembke.nz>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zauberhaus/42/generator"
+)
+
+// EnvCommand adds the env command to export the env bindings
+func EnvCommand(r *RootCommand) {
+	var format string
+	var defaults bool
+
+	envCmd := &cobra.Command{
+		Use:   "env",
+		Short: "Show the environment variables with their values",
+		Args:  cobra.NoArgs,
+		Annotations: map[string]string{
+			skipValidation: "true",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := r.showEnv(format, defaults)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprint(cmd.OutOrStdout(), string(data))
+			return nil
+		},
+	}
+
+	envCmd.Flags().StringVarP(&format, "output", "o", "dotenv", "Output format (dotenv, export, k8s, compose)")
+	envCmd.Flags().BoolVar(&defaults, "defaults", false, "Show the default instead of the current values")
+
+	r.AddCommand(envCmd)
+}
+
+func (r *RootCommand) showEnv(format string, defaults bool) ([]byte, error) {
+	env := r.EnvBindings()
+	config := r.GetConfig()
+
+	if defaults {
+		var err error
+		if config, err = newConfig(reflect.TypeOf(config)); err != nil {
+			return nil, err
+		}
+	}
+
+	bindings := map[string][]string{}
+	for _, k := range configKeys(reflect.TypeOf(config), []string{}) {
+		if v, ok := env[k]; ok {
+			bindings[k] = unique(v)
+		}
+	}
+
+	for _, b := range r.bindings.indexedList() {
+		for k, v := range indexedEnv(b, reflect.ValueOf(config)) {
+			bindings[k] = v
+		}
+	}
+
+	groups, err := generator.GroupBindings(bindings)
+	if err != nil {
+		return nil, err
+	}
+
+	config = generator.Redact(config)
+
+	return generator.MarshalEnv(groups, func(key string) string {
+		value := valueAt(reflect.ValueOf(config), strings.Split(key, "."))
+		if value == nil {
+			return ""
+		}
+
+		return envValue(reflect.ValueOf(value))
+	}, format)
+}
+
+// indexedEnv returns the env vars of the elements of a slice of structs like
+// SERVERS_0_HOST, an empty slice is shown with the vars of the first element
+func indexedEnv(b *indexedBinding, config reflect.Value) map[string][]string {
+	count := 1
+	if v := reflect.ValueOf(valueAt(config, strings.Split(b.key, "."))); v.IsValid() && v.Kind() == reflect.Slice && v.Len() > 0 {
+		count = v.Len()
+	}
+
+	result := map[string][]string{}
+
+	for idx := 0; idx < count; idx++ {
+		namer := newEnvNamer(fmt.Sprintf("%v_%d", b.env, idx), b.naming)
+		path := []string{fmt.Sprintf("%v.%d", b.key, idx)}
+
+		walkEnv(b.elem, path, []string{}, false, namer, func(f reflect.StructField, t reflect.Type, name string, env string, shared bool) {
+			result[strings.ToLower(name)] = []string{env}
+		})
+	}
+
+	return result
+}
+
+// envValue formats a config value like the env vars are parsed, slices are
+// joined with commas and maps are written as sorted key=value pairs
+func envValue(v reflect.Value) string {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+
+		v = v.Elem()
+	}
+
+	if s, ok := stringer(v); ok {
+		return s.String()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = envValue(v.Index(i))
+		}
+
+		return strings.Join(items, ",")
+	case reflect.Map:
+		items := make([]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			items = append(items, fmt.Sprintf("%v=%v", iter.Key().Interface(), envValue(iter.Value())))
+		}
+
+		sort.Strings(items)
+
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprintf("%v", v.Interface())
+	}
+}
+
+func stringer(v reflect.Value) (fmt.Stringer, bool) {
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s, true
+	}
+
+	p := reflect.New(v.Type())
+	p.Elem().Set(v)
+
+	s, ok := p.Interface().(fmt.Stringer)
+
+	return s, ok
+}
@@ -0,0 +1,190 @@
+package cmd_test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zauberhaus/42/cmd"
+)
+
+type EnvConfig struct {
+	Server EnvServer `env:"SERVER"`
+}
+
+type EnvServer struct {
+	Host string `env:"HOST" default:"localhost"`
+	Port int    `env:"PORT" default:"8080"`
+}
+
+type EnvListConfig struct {
+	Tags   []string          `env:"TAGS"`
+	Ports  []int             `env:"PORTS" default:"[80,443]"`
+	Labels map[string]string `env:"LABELS"`
+}
+
+type EnvTaggedConfig struct {
+	ListenAddr string `mapstructure:"listen_addr" default:":8080"`
+	Internal   string `json:"-" default:"internal"`
+}
+
+type EnvIndexedConfig struct {
+	Name    string `default:"test"`
+	Servers []EnvServer
+}
+
+func TestEnvCommand(t *testing.T) {
+	tests := map[string][]string{
+		"dotenv":  {"SERVER_HOST=example.com\nSERVER_PORT=8080\n"},
+		"export":  {"export SERVER_HOST='example.com'\nexport SERVER_PORT='8080'\n"},
+		"k8s":     {"env:\n", "  - name: SERVER_HOST\n    value: \"example.com\"\n  - name: SERVER_PORT\n    value: \"8080\"\n"},
+		"compose": {"environment:\n", "  SERVER_HOST: \"example.com\"\n  SERVER_PORT: \"8080\"\n"},
+	}
+
+	os.Setenv("SERVER_HOST", "example.com")
+	defer os.Unsetenv("SERVER_HOST")
+
+	for format, wanted := range tests {
+		t.Run(t.Name()+"_"+format, func(t *testing.T) {
+			cfg := EnvConfig{}
+
+			rootCmd := cmd.NewRootCmd(
+				&cobra.Command{Use: t.Name(),
+					Short: "Test program",
+				}, &cfg,
+			)
+
+			rootCmd.WithSubCommands(cmd.EnvCommand)
+			rootCmd.SetArgs([]string{"env", "-o", format})
+
+			output := &bytes.Buffer{}
+			rootCmd.SetOut(output)
+
+			err := rootCmd.Execute()
+			if assert.NoError(t, err) {
+				assert.Equal(t, strings.Join(wanted, ""), output.String())
+			}
+		})
+	}
+}
+
+func TestEnvCommandDefaults(t *testing.T) {
+	cfg := EnvConfig{}
+
+	rootCmd := cmd.NewRootCmd(
+		&cobra.Command{Use: t.Name(),
+			Short: "Test program",
+		}, &cfg,
+	)
+
+	os.Setenv("SERVER_HOST", "example.com")
+	defer os.Unsetenv("SERVER_HOST")
+
+	rootCmd.WithSubCommands(cmd.EnvCommand)
+	rootCmd.SetArgs([]string{"env", "--defaults"})
+
+	output := &bytes.Buffer{}
+	rootCmd.SetOut(output)
+
+	err := rootCmd.Execute()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "SERVER_HOST=localhost\nSERVER_PORT=8080\n", output.String())
+	}
+}
+
+func TestEnvCommandLists(t *testing.T) {
+	cfg := EnvListConfig{}
+
+	rootCmd := cmd.NewRootCmd(
+		&cobra.Command{Use: t.Name(),
+			Short: "Test program",
+		}, &cfg,
+	)
+
+	os.Setenv("TAGS", "a,b")
+	os.Setenv("LABELS", "env=prod,app=test")
+	defer os.Unsetenv("TAGS")
+	defer os.Unsetenv("LABELS")
+
+	rootCmd.WithSubCommands(cmd.EnvCommand)
+	rootCmd.SetArgs([]string{"env"})
+
+	output := &bytes.Buffer{}
+	rootCmd.SetOut(output)
+
+	err := rootCmd.Execute()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "LABELS=\"app=test,env=prod\"\n\nPORTS=80,443\n\nTAGS=a,b\n", output.String())
+	}
+}
+
+func TestEnvCommandTagged(t *testing.T) {
+	cfg := EnvTaggedConfig{}
+
+	rootCmd := cmd.NewRootCmd(
+		&cobra.Command{Use: t.Name(),
+			Short: "Test program",
+		}, &cfg,
+	)
+
+	rootCmd.WithSubCommands(cmd.EnvCommand)
+	rootCmd.SetArgs([]string{"env"})
+
+	output := &bytes.Buffer{}
+	rootCmd.SetOut(output)
+
+	err := rootCmd.Execute()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "LISTEN_ADDR=:8080\n", output.String())
+	}
+}
+
+func TestEnvCommandIndexed(t *testing.T) {
+	tests := map[string]struct {
+		env    map[string]string
+		wanted string
+	}{
+		"template": {
+			wanted: "NAME=test\n\nSERVERS_0_HOST=\"\"\nSERVERS_0_PORT=\"\"\n",
+		},
+		"elements": {
+			env: map[string]string{
+				"SERVERS_0_HOST": "a",
+				"SERVERS_1_HOST": "b",
+				"SERVERS_1_PORT": "81",
+			},
+			wanted: "NAME=test\n\nSERVERS_0_HOST=a\nSERVERS_0_PORT=0\n\nSERVERS_1_HOST=b\nSERVERS_1_PORT=81\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			for k, v := range test.env {
+				os.Setenv(k, v)
+				defer os.Unsetenv(k)
+			}
+
+			cfg := EnvIndexedConfig{}
+
+			rootCmd := cmd.NewRootCmd(
+				&cobra.Command{Use: t.Name(),
+					Short: "Test program",
+				}, &cfg,
+			)
+
+			rootCmd.WithSubCommands(cmd.EnvCommand)
+			rootCmd.SetArgs([]string{"env"})
+
+			output := &bytes.Buffer{}
+			rootCmd.SetOut(output)
+
+			err := rootCmd.Execute()
+			if assert.NoError(t, err) {
+				assert.Equal(t, test.wanted, output.String())
+			}
+		})
+	}
+}
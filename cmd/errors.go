@@ -0,0 +1,138 @@
+/*
+Copyright © 2021 Dirk Lembke <dirk@lembke.nz>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Exit codes of Main
+const (
+	ExitOK         = 0
+	ExitRuntime    = 1
+	ExitUsage      = 2
+	ExitConfig     = 3
+	ExitValidation = 4
+)
+
+// ConfigError is returned if the config can't be bound, read or decoded
+type ConfigError struct {
+	Err error
+}
+
+func (e *ConfigError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// UsageError is returned for invalid commands, args or flags
+type UsageError struct {
+	Err error
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+// RuntimeError is returned for errors of the command itself
+type RuntimeError struct {
+	Err error
+}
+
+func (e *RuntimeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RuntimeError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code for the type of the error
+func ExitCode(err error) int {
+	var (
+		configErr     *ConfigError
+		validationErr *ValidationError
+		usageErr      *UsageError
+	)
+
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &configErr):
+		return ExitConfig
+	case errors.As(err, &validationErr):
+		return ExitValidation
+	case errors.As(err, &usageErr):
+		return ExitUsage
+	default:
+		return ExitRuntime
+	}
+}
+
+// wrapError types errors returned by cobra, errors before the pre run are
+// usage errors and all other untyped errors are runtime errors
+func (r *RootCommand) wrapError(err error) error {
+	var (
+		configErr     *ConfigError
+		validationErr *ValidationError
+		usageErr      *UsageError
+		runtimeErr    *RuntimeError
+	)
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &configErr), errors.As(err, &validationErr), errors.As(err, &usageErr), errors.As(err, &runtimeErr):
+		return err
+	case !r.preRun:
+		return &UsageError{Err: err}
+	default:
+		return &RuntimeError{Err: err}
+	}
+}
+
+// checkRequiredFlags returns the error of cobra for missing required flags,
+// which is checked by cobra only after the pre run
+func checkRequiredFlags(cmd *cobra.Command) error {
+	missing := []string{}
+
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if required, ok := f.Annotations[cobra.BashCompOneRequiredFlag]; ok && len(required) > 0 && required[0] == "true" && !f.Changed {
+			missing = append(missing, f.Name)
+		}
+	})
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf(`required flag(s) "%s" not set`, strings.Join(missing, `", "`))
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package cmd_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zauberhaus/42/cmd"
+)
+
+type ErrorConfig struct {
+	Name string `validate:"required"`
+	Port int    `flag:"port"`
+}
+
+func TestErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid.yaml")
+	invalid := filepath.Join(dir, "invalid.yaml")
+	cycle := filepath.Join(dir, "cycle.yaml")
+
+	assert.NoError(t, os.WriteFile(valid, []byte("name: test\n"), 0600))
+	assert.NoError(t, os.WriteFile(invalid, []byte("name: [test\n"), 0600))
+	assert.NoError(t, os.WriteFile(cycle, []byte("name: ${port}\nport: ${name}\n"), 0600))
+
+	failed := errors.New("failed")
+
+	tests := map[string]struct {
+		args  []string
+		run   error
+		check func(err error) bool
+		code  int
+	}{
+		"ok": {
+			args: []string{"--config", valid},
+			code: cmd.ExitOK,
+		},
+		"runtime": {
+			args:  []string{"--config", valid},
+			run:   failed,
+			check: func(err error) bool { var e *cmd.RuntimeError; return errors.As(err, &e) && errors.Is(err, failed) },
+			code:  cmd.ExitRuntime,
+		},
+		"unknown flag": {
+			args:  []string{"--unknown"},
+			check: func(err error) bool { var e *cmd.UsageError; return errors.As(err, &e) },
+			code:  cmd.ExitUsage,
+		},
+		"args": {
+			args:  []string{"--config", valid, "arg"},
+			check: func(err error) bool { var e *cmd.UsageError; return errors.As(err, &e) },
+			code:  cmd.ExitUsage,
+		},
+		"missing config": {
+			args: []string{"--config", filepath.Join(dir, "missing.yaml")},
+			check: func(err error) bool {
+				var e *cmd.ConfigError
+				return errors.As(err, &e) && os.IsNotExist(errors.Unwrap(err))
+			},
+			code: cmd.ExitConfig,
+		},
+		"invalid config": {
+			args:  []string{"--config", invalid},
+			check: func(err error) bool { var e *cmd.ConfigError; return errors.As(err, &e) },
+			code:  cmd.ExitConfig,
+		},
+		"interpolation cycle": {
+			args: []string{"--config", cycle},
+			check: func(err error) bool {
+				var e *cmd.ConfigError
+				return errors.As(err, &e) && err.Error() == "Interpolate name: Interpolation cycle: name -> port -> name"
+			},
+			code: cmd.ExitConfig,
+		},
+		"validation": {
+			args:  []string{},
+			check: func(err error) bool { var e *cmd.ValidationError; return errors.As(err, &e) },
+			code:  cmd.ExitValidation,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := ErrorConfig{}
+			rootCmd := cmd.NewRootCmd(&cobra.Command{
+				Use:           "test",
+				Args:          cobra.NoArgs,
+				SilenceErrors: true,
+				SilenceUsage:  true,
+				RunE: func(cmd *cobra.Command, args []string) error {
+					return tt.run
+				},
+			}, &cfg)
+
+			rootCmd.SetArgs(tt.args)
+			err := rootCmd.Execute()
+
+			if tt.check == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, tt.check(err), fmt.Sprintf("%T: %v", err, err))
+			}
+
+			assert.Equal(t, tt.code, cmd.ExitCode(err))
+		})
+	}
+}
+
+func TestRequiredFlag(t *testing.T) {
+	cfg := ErrorConfig{}
+	rootCmd := cmd.NewRootCmd(&cobra.Command{
+		Use:           "test",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		Run:           func(cmd *cobra.Command, args []string) {},
+	}, &cfg)
+
+	rootCmd.MarkPersistentFlagRequired("port")
+	rootCmd.SetArgs([]string{})
+
+	err := rootCmd.Execute()
+	if assert.Error(t, err) {
+		assert.Equal(t, `required flag(s) "port" not set`, err.Error())
+		assert.Equal(t, cmd.ExitUsage, cmd.ExitCode(err))
+	}
+}
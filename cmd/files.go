@@ -0,0 +1,334 @@
+/*
+Copyright © 2021 Dirk Lembke <dirk@lembke.nz>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/viper"
+	"github.com/zauberhaus/42/logger"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	includeKey  = "include"
+	profilesKey = "profiles"
+)
+
+type configLayer struct {
+	file  string
+	viper *viper.Viper
+	// settings of the file with all profiles for the strict check
+	settings map[string]interface{}
+}
+
+// configFiles returns the existing config files in the order they are merged:
+// /etc/<name>/config.*, <XDG_CONFIG_DIRS>/<name>/config.*, <XDG_CONFIG_HOME>/<name>/config.*,
+// $HOME/<name>.*, ./<name>.* and at last the file set by --config or $<PREFIX>CONFIG
+func (r *RootCommand) configFiles() ([]string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return nil, fmt.Errorf("Get homedir: %v", err)
+	}
+
+	name := r.defaultConfigFile
+
+	dirs := []string{filepath.Join("/etc", name)}
+
+	xdgDirs := os.Getenv("XDG_CONFIG_DIRS")
+	if xdgDirs == "" {
+		xdgDirs = "/etc/xdg"
+	}
+
+	list := filepath.SplitList(xdgDirs)
+	for i := len(list) - 1; i >= 0; i-- {
+		if list[i] != "" {
+			dirs = append(dirs, filepath.Join(list[i], name))
+		}
+	}
+
+	xdgHome := os.Getenv("XDG_CONFIG_HOME")
+	if xdgHome == "" {
+		xdgHome = filepath.Join(home, ".config")
+	}
+
+	dirs = append(dirs, filepath.Join(xdgHome, name))
+
+	files := []string{}
+	for _, dir := range dirs {
+		if file := findConfigFile(dir, "config"); file != "" {
+			files = append(files, file)
+		}
+	}
+
+	if file := findConfigFile(home, name); file != "" {
+		files = append(files, file)
+	}
+
+	if cwd, err := os.Getwd(); err == nil {
+		if file := findConfigFile(cwd, name); file != "" {
+			files = append(files, file)
+		}
+	}
+
+	explicit := r.configFile
+	if explicit == "" {
+		explicit = os.Getenv(newEnvNamer(r.envPrefix, nil).tagged([]string{"CONFIG"}))
+	}
+
+	if explicit != "" {
+		if _, err := os.Stat(explicit); err != nil {
+			return nil, err
+		}
+
+		files = append(files, explicit)
+	}
+
+	return unique(files), nil
+}
+
+func findConfigFile(dir string, name string) string {
+	for _, ext := range viper.SupportedExts {
+		file := filepath.Join(dir, name+"."+ext)
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			return file
+		}
+	}
+
+	return ""
+}
+
+// readConfig reads all config files with their includes and merges them
+// into the viper config of the command
+func (r *RootCommand) readConfig() ([]string, error) {
+	files, err := r.configFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	profile := r.Profile()
+	found := false
+
+	layers := []*configLayer{}
+	for _, file := range files {
+		list, err := readLayers(file, map[string]bool{})
+		if err != nil {
+			return nil, err
+		}
+
+		if profile != "" {
+			name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+			if sibling := findConfigFile(filepath.Dir(file), name+"."+profile); sibling != "" {
+				tmp, err := readLayers(sibling, map[string]bool{})
+				if err != nil {
+					return nil, err
+				}
+
+				list = append(list, tmp...)
+				found = true
+			}
+		}
+
+		layers = append(layers, list...)
+	}
+
+	for _, l := range layers {
+		ok, err := applyProfile(l, profile)
+		if err != nil {
+			return nil, err
+		}
+
+		found = found || ok
+	}
+
+	if profile != "" && !found {
+		logger.Warnf("Profile not found: %v", profile)
+	}
+
+	merged := viper.New()
+	files = []string{}
+
+	for _, l := range layers {
+		merged.MergeConfigMap(l.viper.AllSettings())
+		files = append(files, l.file)
+	}
+
+	if err := setConfig(r.viper, merged.AllSettings()); err != nil {
+		return nil, err
+	}
+
+	settings, err := interpolate(r.viper, merged.AllSettings())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := setConfig(r.viper, settings); err != nil {
+		return nil, err
+	}
+
+	if len(files) > 0 {
+		r.viper.SetConfigFile(files[len(files)-1])
+	}
+
+	r.bindings.applyIndexedEnv(r.viper)
+
+	r.mutex.Lock()
+	r.layers = layers
+	r.mutex.Unlock()
+
+	return unique(files), nil
+}
+
+// readLayers reads a config file and the files of its include list, the
+// included files are merged in order before the values of the file itself
+func readLayers(file string, visited map[string]bool) ([]*configLayer, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if visited[abs] {
+		return nil, fmt.Errorf("Include cycle: %v", file)
+	}
+
+	visited[abs] = true
+	defer delete(visited, abs)
+
+	v := viper.New()
+	v.SetConfigFile(file)
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("Read config file %v: %v", file, err)
+	}
+
+	layers := []*configLayer{}
+
+	if v.IsSet(includeKey) {
+		for _, pattern := range v.GetStringSlice(includeKey) {
+			if !filepath.IsAbs(pattern) {
+				pattern = filepath.Join(filepath.Dir(file), pattern)
+			}
+
+			matches, err := filepath.Glob(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("Include %v in %v: %v", pattern, file, err)
+			}
+
+			if len(matches) == 0 && !hasMeta(pattern) {
+				return nil, fmt.Errorf("Include %v in %v: file not found", pattern, file)
+			}
+
+			for _, match := range matches {
+				if info, err := os.Stat(match); err == nil && info.IsDir() {
+					continue
+				}
+
+				list, err := readLayers(match, visited)
+				if err != nil {
+					return nil, err
+				}
+
+				layers = append(layers, list...)
+			}
+		}
+
+		settings := v.AllSettings()
+		delete(settings, includeKey)
+
+		v = viper.New()
+		if err := setConfig(v, settings); err != nil {
+			return nil, err
+		}
+	}
+
+	return append(layers, &configLayer{
+		file:     file,
+		viper:    v,
+		settings: v.AllSettings(),
+	}), nil
+}
+
+// applyProfile merges the section of the profile in the profiles map of
+// the layer over its base values and removes the profiles map
+func applyProfile(l *configLayer, profile string) (bool, error) {
+	if !l.viper.IsSet(profilesKey) {
+		return false, nil
+	}
+
+	settings := l.viper.AllSettings()
+	profiles, ok := settings[profilesKey].(map[string]interface{})
+	if !ok {
+		return false, fmt.Errorf("Invalid profiles in %v", l.file)
+	}
+
+	delete(settings, profilesKey)
+
+	v := viper.New()
+	if err := setConfig(v, settings); err != nil {
+		return false, err
+	}
+
+	overlay, found := profiles[strings.ToLower(profile)]
+	if profile != "" && found {
+		values, ok := overlay.(map[string]interface{})
+		if !ok {
+			return false, fmt.Errorf("Invalid profile %v in %v", profile, l.file)
+		}
+
+		if err := v.MergeConfigMap(values); err != nil {
+			return false, err
+		}
+	}
+
+	l.viper = v
+
+	return profile != "" && found, nil
+}
+
+func hasMeta(path string) bool {
+	return strings.ContainsAny(path, `*?[\`)
+}
+
+// setConfig replaces the config values of v
+func setConfig(v *viper.Viper, config map[string]interface{}) error {
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	v.SetConfigType("yaml")
+	return v.ReadConfig(bytes.NewReader(data))
+}
+
+// configFileOf returns the last config file, which contains the key
+func (r *RootCommand) configFileOf(key string) string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for i := len(r.layers) - 1; i >= 0; i-- {
+		if r.layers[i].viper.InConfig(key) {
+			return r.layers[i].file
+		}
+	}
+
+	return ""
+}
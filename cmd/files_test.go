@@ -0,0 +1,143 @@
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zauberhaus/42/cmd"
+)
+
+type LayerConfig struct {
+	First  string
+	Second string
+	Third  string
+	Fourth string
+}
+
+func TestConfigLayers(t *testing.T) {
+	dir := t.TempDir()
+	name := "layers"
+
+	files := map[string]string{
+		filepath.Join(dir, "xdg1", name, "config.yaml"): "first: xdg1\nsecond: xdg1\nthird: xdg1\nfourth: xdg1\n",
+		filepath.Join(dir, "xdg2", name, "config.json"): `{"second": "xdg2", "third": "xdg2", "fourth": "xdg2"}`,
+		filepath.Join(dir, "home", name, "config.toml"): "third = \"home\"\nfourth = \"home\"\n",
+		filepath.Join(dir, "explicit", "override.yaml"): "fourth: explicit\n",
+	}
+
+	for file, content := range files {
+		err := os.MkdirAll(filepath.Dir(file), 0700)
+		if assert.NoError(t, err) {
+			err = ioutil.WriteFile(file, []byte(content), 0600)
+			assert.NoError(t, err)
+		}
+	}
+
+	os.Setenv("XDG_CONFIG_DIRS", filepath.Join(dir, "xdg2")+string(filepath.ListSeparator)+filepath.Join(dir, "xdg1"))
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "home"))
+	defer os.Unsetenv("XDG_CONFIG_DIRS")
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+
+	cfg := LayerConfig{}
+
+	rootCmd := cmd.NewRootCmd(
+		&cobra.Command{Use: name,
+			Short: "Test program",
+		}, &cfg,
+	)
+
+	rootCmd.WithSubCommands(cmd.ConfigCommand)
+
+	output := &bytes.Buffer{}
+	rootCmd.SetOut(output)
+
+	explicit := filepath.Join(dir, "explicit", "override.yaml")
+	rootCmd.SetArgs([]string{"config", "show", "--sources", "--config", explicit})
+
+	err := rootCmd.Execute()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "xdg1", cfg.First)
+		assert.Equal(t, "xdg2", cfg.Second)
+		assert.Equal(t, "home", cfg.Third)
+		assert.Equal(t, "explicit", cfg.Fourth)
+
+		assert.Equal(t, "first: xdg1 # file "+filepath.Join(dir, "xdg1", name, "config.yaml")+"\n"+
+			"second: xdg2 # file "+filepath.Join(dir, "xdg2", name, "config.json")+"\n"+
+			"third: home # file "+filepath.Join(dir, "home", name, "config.toml")+"\n"+
+			"fourth: explicit # file "+explicit+"\n", output.String())
+	}
+}
+
+type IncludeConfig struct {
+	Name     string
+	Value    int
+	User     string
+	Password string
+}
+
+func TestConfigInclude(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		filepath.Join(dir, "config.yaml"):         "include: [base.yaml, secrets/*]\nname: main\n",
+		filepath.Join(dir, "base.yaml"):           "name: base\nvalue: 1\nuser: base\n",
+		filepath.Join(dir, "secrets", "a.toml"):   "user = \"a\"\npassword = \"a\"\n",
+		filepath.Join(dir, "secrets", "b.json"):   `{"password": "b"}`,
+		filepath.Join(dir, "secrets", "c", "d.x"): "ignored",
+	}
+
+	for file, content := range files {
+		err := os.MkdirAll(filepath.Dir(file), 0700)
+		if assert.NoError(t, err) {
+			err = ioutil.WriteFile(file, []byte(content), 0600)
+			assert.NoError(t, err)
+		}
+	}
+
+	os.Remove(filepath.Join(dir, "secrets", "c", "d.x"))
+
+	cfg := IncludeConfig{}
+	changed := make(chan *IncludeConfig, 1)
+
+	rootCmd := cmd.NewRootCmd(
+		&cobra.Command{Use: t.Name(),
+			Short: "Test program",
+			Run: func(cmd *cobra.Command, args []string) {
+				assert.Equal(t, "main", cfg.Name)
+				assert.Equal(t, 1, cfg.Value)
+				assert.Equal(t, "a", cfg.User)
+				assert.Equal(t, "b", cfg.Password)
+
+				err := ioutil.WriteFile(filepath.Join(dir, "base.yaml"), []byte("name: base\nvalue: 2\n"), 0600)
+				assert.NoError(t, err)
+
+				select {
+				case c := <-changed:
+					assert.Equal(t, "main", c.Name)
+					assert.Equal(t, 2, c.Value)
+					assert.Equal(t, "b", c.Password)
+				case <-time.After(5 * time.Second):
+					t.Error("Config change not received")
+				}
+			},
+		}, &cfg,
+	)
+
+	rootCmd.OnConfigChange(func(old interface{}, new interface{}) {
+		select {
+		case changed <- new.(*IncludeConfig):
+		default:
+		}
+	})
+
+	rootCmd.SetArgs([]string{"--config", filepath.Join(dir, "config.yaml")})
+
+	err := rootCmd.Execute()
+	assert.NoError(t, err)
+}
@@ -0,0 +1,247 @@
+package cmd_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zauberhaus/42/cmd"
+)
+
+type FlagConfig struct {
+	Listen  string        `flag:"listen-addr" short:"a" default:":8080" usage:"Listen address"`
+	Debug   bool          `flag:"debug" usage:"Debug mode"`
+	Workers int           `flag:"workers" short:"w" default:"4" usage:"Number of workers"`
+	Timeout time.Duration `flag:"timeout" default:"5s" usage:"Timeout"`
+	Tags    []string      `flag:"tags" usage:"Tags"`
+	Server  FlagServer
+}
+
+type FlagServer struct {
+	Ratio float64 `flag:"ratio" default:"0.5" usage:"Ratio"`
+	Name  string
+}
+
+type FlagSubConfig struct {
+	Count int `flag:"count" default:"3" usage:"Count"`
+}
+
+type FlagKindConfig struct {
+	Small  int8    `flag:"small" default:"-1"`
+	Port   uint16  `flag:"port" default:"80"`
+	Limit  uint    `flag:"limit" default:"10"`
+	Factor float32 `flag:"factor" default:"1.5"`
+}
+
+type FlagErrorConfig struct {
+	Ports []int `flag:"ports"`
+}
+
+type FlagShortConfig struct {
+	Level string `flag:"level" short:"l"`
+}
+
+type FlagShortDuplicateConfig struct {
+	Name string `flag:"name" short:"n"`
+	Node string `flag:"node" short:"n"`
+}
+
+func TestFlagDefaults(t *testing.T) {
+	t.Parallel()
+
+	cfg := FlagConfig{}
+
+	rootCmd := cmd.NewRootCmd(
+		&cobra.Command{Use: t.Name(),
+			Short: "Test program",
+			Run: func(c *cobra.Command, args []string) {
+				assert.Equal(t, ":8080", cfg.Listen)
+				assert.Equal(t, false, cfg.Debug)
+				assert.Equal(t, 4, cfg.Workers)
+				assert.Equal(t, 5*time.Second, cfg.Timeout)
+				assert.Equal(t, 0.5, cfg.Server.Ratio)
+			},
+		}, &cfg,
+	)
+
+	flag := rootCmd.PersistentFlags().Lookup("listen-addr")
+	if assert.NotNil(t, flag) {
+		assert.Equal(t, "a", flag.Shorthand)
+		assert.Equal(t, ":8080", flag.DefValue)
+		assert.Equal(t, "Listen address", flag.Usage)
+	}
+
+	assert.Nil(t, rootCmd.PersistentFlags().Lookup("name"))
+
+	rootCmd.SetArgs([]string{})
+	err := rootCmd.Execute()
+	assert.NoError(t, err)
+}
+
+func TestFlagValues(t *testing.T) {
+	t.Parallel()
+
+	cfg := FlagConfig{}
+
+	rootCmd := cmd.NewRootCmd(
+		&cobra.Command{Use: t.Name(),
+			Short: "Test program",
+			Run: func(c *cobra.Command, args []string) {
+				assert.Equal(t, "localhost:9090", cfg.Listen)
+				assert.Equal(t, true, cfg.Debug)
+				assert.Equal(t, 8, cfg.Workers)
+				assert.Equal(t, time.Minute, cfg.Timeout)
+				assert.Equal(t, []string{"a", "b"}, cfg.Tags)
+				assert.Equal(t, 0.75, cfg.Server.Ratio)
+
+				workers, err := c.Flags().GetInt("workers")
+				assert.NoError(t, err)
+				assert.Equal(t, 8, workers)
+			},
+		}, &cfg,
+	)
+
+	rootCmd.SetArgs([]string{"-a", "localhost:9090", "--debug", "-w", "8", "--timeout", "1m", "--tags", "a,b", "--ratio", "0.75"})
+	err := rootCmd.Execute()
+	assert.NoError(t, err)
+}
+
+func TestFlagSubCommand(t *testing.T) {
+	t.Parallel()
+
+	cfg := FlagConfig{}
+
+	rootCmd := cmd.NewRootCmd(
+		&cobra.Command{Use: t.Name(),
+			Short: "Test program",
+		}, &cfg,
+	)
+
+	rootCmd.WithSubCommands(func(rc *cmd.RootCommand) {
+		subCmd := &cobra.Command{
+			Use: "sub",
+			Run: func(c *cobra.Command, args []string) {
+				assert.Equal(t, 0.25, cfg.Server.Ratio)
+
+				count, err := c.Flags().GetInt("count")
+				assert.NoError(t, err)
+				assert.Equal(t, 5, count)
+			},
+		}
+
+		err := rc.AutoBindFlags(subCmd.Flags(), &FlagSubConfig{})
+		assert.NoError(t, err)
+
+		err = rc.AutoBindFlags(subCmd.Flags(), &FlagSubConfig{})
+		assert.EqualError(t, err, "Flag already defined: count")
+
+		rc.AddCommand(subCmd)
+	})
+
+	rootCmd.SetArgs([]string{"sub", "--ratio", "0.25", "--count", "5"})
+	err := rootCmd.Execute()
+	assert.NoError(t, err)
+}
+
+func TestFlagKinds(t *testing.T) {
+	t.Parallel()
+
+	cfg := FlagKindConfig{}
+
+	rootCmd := cmd.NewRootCmd(
+		&cobra.Command{Use: t.Name(),
+			Short: "Test program",
+			Run: func(c *cobra.Command, args []string) {
+				assert.Equal(t, int8(-1), cfg.Small)
+				assert.Equal(t, uint16(8080), cfg.Port)
+				assert.Equal(t, uint(10), cfg.Limit)
+				assert.Equal(t, float32(1.5), cfg.Factor)
+
+				small, err := c.Flags().GetInt8("small")
+				assert.NoError(t, err)
+				assert.Equal(t, int8(-1), small)
+
+				port, err := c.Flags().GetUint16("port")
+				assert.NoError(t, err)
+				assert.Equal(t, uint16(8080), port)
+
+				limit, err := c.Flags().GetUint("limit")
+				assert.NoError(t, err)
+				assert.Equal(t, uint(10), limit)
+
+				factor, err := c.Flags().GetFloat32("factor")
+				assert.NoError(t, err)
+				assert.Equal(t, float32(1.5), factor)
+			},
+		}, &cfg,
+	)
+
+	rootCmd.SetArgs([]string{"--port", "8080"})
+	err := rootCmd.Execute()
+	assert.NoError(t, err)
+}
+
+func TestFlagBindError(t *testing.T) {
+	t.Parallel()
+
+	cfg := FlagErrorConfig{}
+
+	rootCmd := cmd.NewRootCmd(
+		&cobra.Command{Use: t.Name(),
+			Short: "Test program",
+			Run: func(c *cobra.Command, args []string) {
+				assert.Fail(t, "Command shouldn't run")
+			},
+		}, &cfg,
+	)
+
+	rootCmd.SilenceErrors = true
+	rootCmd.SilenceUsage = true
+
+	rootCmd.SetArgs([]string{})
+	err := rootCmd.Execute()
+
+	var configErr *cmd.ConfigError
+	if assert.ErrorAs(t, err, &configErr) {
+		assert.EqualError(t, err, "Bind flags: Flag ports: Unsupported slice type: []int")
+	}
+}
+
+func TestFlagShorthandError(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config interface{}
+		wanted string
+	}{
+		"builtin":   {config: &FlagShortConfig{}, wanted: "Bind flags: Flag already defined: -l"},
+		"duplicate": {config: &FlagShortDuplicateConfig{}, wanted: "Bind flags: Flag already defined: -n"},
+	}
+
+	for name, test := range tests {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			rootCmd := cmd.NewRootCmd(
+				&cobra.Command{Use: t.Name(),
+					Short: "Test program",
+					Run: func(c *cobra.Command, args []string) {
+						assert.Fail(t, "Command shouldn't run")
+					},
+				}, test.config,
+			)
+
+			rootCmd.SilenceErrors = true
+			rootCmd.SilenceUsage = true
+
+			rootCmd.SetArgs([]string{})
+			err := rootCmd.Execute()
+
+			var configErr *cmd.ConfigError
+			if assert.ErrorAs(t, err, &configErr) {
+				assert.EqualError(t, err, test.wanted)
+			}
+		})
+	}
+}
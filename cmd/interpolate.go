@@ -0,0 +1,224 @@
+/*
+Copyright © 2021 Dirk Lembke <dirk@lembke.nz>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// interpolator resolves ${VAR}, ${VAR:-default} and ${key.path} references
+// with env vars and config values of a viper instance
+type interpolator struct {
+	viper    *viper.Viper
+	resolved map[string]string
+	stack    []string
+}
+
+// interpolate returns a copy of the settings with all references in string
+// values resolved, $${ is written as ${
+func interpolate(v *viper.Viper, settings map[string]interface{}) (map[string]interface{}, error) {
+	i := &interpolator{
+		viper:    v,
+		resolved: map[string]string{},
+	}
+
+	result, err := i.walk(settings, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return result.(map[string]interface{}), nil
+}
+
+func (i *interpolator) walk(value interface{}, key string) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+
+		result := make(map[string]interface{}, len(v))
+		for _, k := range keys {
+			item := v[k]
+			path := k
+			if key != "" {
+				path = key + "." + k
+			}
+
+			val, err := i.walk(item, path)
+			if err != nil {
+				return nil, err
+			}
+
+			result[k] = val
+		}
+
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for idx, item := range v {
+			val, err := i.walk(item, key)
+			if err != nil {
+				return nil, err
+			}
+
+			result[idx] = val
+		}
+
+		return result, nil
+	case string:
+		if !strings.Contains(v, "${") {
+			return v, nil
+		}
+
+		i.stack = append(i.stack, key)
+		defer i.pop()
+
+		val, err := i.expand(v)
+		if err != nil {
+			return nil, fmt.Errorf("Interpolate %v: %v", key, err)
+		}
+
+		return val, nil
+	default:
+		return value, nil
+	}
+}
+
+func (i *interpolator) pop() {
+	i.stack = i.stack[:len(i.stack)-1]
+}
+
+// expand replaces all references in s
+func (i *interpolator) expand(s string) (string, error) {
+	var b strings.Builder
+
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+
+		if start > 0 && s[start-1] == '$' {
+			b.WriteString(s[:start-1] + "${")
+			s = s[start+2:]
+			continue
+		}
+
+		end := closingBrace(s, start+2)
+		if end < 0 {
+			return "", fmt.Errorf("Unterminated reference: %v", s[start:])
+		}
+
+		val, err := i.lookup(s[start+2 : end])
+		if err != nil {
+			return "", err
+		}
+
+		b.WriteString(s[:start] + val)
+		s = s[end+1:]
+	}
+}
+
+func closingBrace(s string, from int) int {
+	depth := 0
+	for idx := from; idx < len(s); idx++ {
+		switch {
+		case strings.HasPrefix(s[idx:], "${"):
+			depth++
+			idx++
+		case s[idx] == '}':
+			if depth == 0 {
+				return idx
+			}
+
+			depth--
+		}
+	}
+
+	return -1
+}
+
+// lookup resolves an expression like VAR, VAR:-default or key.path
+func (i *interpolator) lookup(expr string) (string, error) {
+	name, def, hasDefault := strings.Cut(expr, ":-")
+	name = strings.TrimSpace(name)
+
+	if val, ok := os.LookupEnv(name); ok && (val != "" || !hasDefault) {
+		return val, nil
+	}
+
+	if i.viper.IsSet(name) {
+		val, err := i.resolve(strings.ToLower(name))
+		if err != nil {
+			return "", err
+		}
+
+		if val != "" || !hasDefault {
+			return val, nil
+		}
+	}
+
+	if hasDefault {
+		return i.expand(def)
+	}
+
+	return "", fmt.Errorf("Undefined variable: %v", name)
+}
+
+// resolve returns the interpolated value of a config key
+func (i *interpolator) resolve(key string) (string, error) {
+	if val, ok := i.resolved[key]; ok {
+		return val, nil
+	}
+
+	for idx, k := range i.stack {
+		if k == key {
+			return "", fmt.Errorf("Interpolation cycle: %v", strings.Join(append(i.stack[idx:], key), " -> "))
+		}
+	}
+
+	var val string
+
+	switch v := i.viper.Get(key).(type) {
+	case map[string]interface{}, []interface{}:
+		return "", fmt.Errorf("Reference to a non scalar value: %v", key)
+	case string:
+		i.stack = append(i.stack, key)
+		defer i.pop()
+
+		var err error
+		if val, err = i.expand(v); err != nil {
+			return "", err
+		}
+	default:
+		val = fmt.Sprint(v)
+	}
+
+	i.resolved[key] = val
+
+	return val, nil
+}
@@ -0,0 +1,121 @@
+package cmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zauberhaus/42/cmd"
+)
+
+type InterpolatedConfig struct {
+	Host    string
+	Port    int
+	URL     string
+	Name    string
+	Escaped string
+	Nested  string
+	List    []string
+}
+
+const interpolatedFile = `host: ${INTERPOLATE_HOST}
+port: 8080
+url: http://${host}:${port}/${name}
+name: ${INTERPOLATE_NAME:-default}
+escaped: $${host}
+nested: ${INTERPOLATE_NAME:-${host}}
+list:
+  - ${port}
+  - static
+`
+
+func TestInterpolation(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if !assert.NoError(t, os.WriteFile(file, []byte(interpolatedFile), 0600)) {
+		return
+	}
+
+	os.Setenv("INTERPOLATE_HOST", "example.com")
+	defer os.Unsetenv("INTERPOLATE_HOST")
+
+	cfg := InterpolatedConfig{}
+	rootCmd := cmd.NewRootCmd(&cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {},
+	}, &cfg)
+
+	rootCmd.SetArgs([]string{"--config", file})
+
+	if assert.NoError(t, rootCmd.Execute()) {
+		assert.Equal(t, InterpolatedConfig{
+			Host:    "example.com",
+			Port:    8080,
+			URL:     "http://example.com:8080/default",
+			Name:    "default",
+			Escaped: "${host}",
+			Nested:  "example.com",
+			List:    []string{"8080", "static"},
+		}, cfg)
+	}
+}
+
+type InterpolationErrorConfig struct {
+	A string
+	B interface{}
+}
+
+func TestInterpolationErrors(t *testing.T) {
+	tests := map[string]struct {
+		config string
+		wanted string
+	}{
+		"cycle": {
+			config: "a: ${b}\nb: ${a}\n",
+			wanted: "Interpolate a: Interpolation cycle: a -> b -> a",
+		},
+		"undefined": {
+			config: "a: ${INTERPOLATE_MISSING}\n",
+			wanted: "Interpolate a: Undefined variable: INTERPOLATE_MISSING",
+		},
+		"unterminated": {
+			config: "a: http://${b\nb: x\n",
+			wanted: "Interpolate a: Unterminated reference: ${b",
+		},
+		"non scalar": {
+			config: "a: ${b}\nb:\n  c: x\n",
+			wanted: "Interpolate a: Reference to a non scalar value: b",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "config.yaml")
+			if !assert.NoError(t, os.WriteFile(file, []byte(test.config), 0600)) {
+				return
+			}
+
+			cfg := InterpolationErrorConfig{}
+			rootCmd := cmd.NewRootCmd(&cobra.Command{
+				Use: "test",
+				Run: func(c *cobra.Command, args []string) {
+					assert.Fail(t, "Command shouldn't run")
+				},
+			}, &cfg)
+
+			rootCmd.SilenceErrors = true
+			rootCmd.SilenceUsage = true
+
+			rootCmd.SetArgs([]string{"--config", file})
+			err := rootCmd.Execute()
+
+			var configErr *cmd.ConfigError
+			if assert.ErrorAs(t, err, &configErr) {
+				assert.EqualError(t, err, test.wanted)
+			}
+		})
+	}
+}
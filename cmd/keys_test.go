@@ -0,0 +1,150 @@
+package cmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zauberhaus/42/cmd"
+	"github.com/zauberhaus/42/generator"
+)
+
+type TaggedConfig struct {
+	LogLevel string       `yaml:"log_level"`
+	Server   TaggedServer `json:"server_opts"`
+	Servers  []TaggedServer
+}
+
+type TaggedServer struct {
+	Port int    `mapstructure:"listen_port" yaml:"port" validate:"max=1000"`
+	Host string `json:"host_name,omitempty"`
+}
+
+func TestTaggedKeys(t *testing.T) {
+	wanted := TaggedConfig{
+		LogLevel: "debug",
+		Server:   TaggedServer{Port: 80, Host: "localhost"},
+		Servers:  []TaggedServer{{Port: 81, Host: "a"}, {Port: 82, Host: "b"}},
+	}
+
+	for _, format := range []string{"yaml", "json", "toml", "hcl"} {
+		t.Run(format, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "config."+format)
+
+			data, err := generator.Marshal(wanted, file)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			if !assert.NoError(t, os.WriteFile(file, data, 0644)) {
+				return
+			}
+
+			cfg := TaggedConfig{}
+			rootCmd := cmd.NewRootCmd(&cobra.Command{
+				Use: "test",
+				Run: func(cmd *cobra.Command, args []string) {},
+			}, &cfg)
+
+			rootCmd.SetArgs([]string{"--config", file})
+			if assert.NoError(t, rootCmd.Execute()) {
+				assert.Equal(t, wanted, cfg)
+			}
+		})
+	}
+}
+
+func TestTaggedEnv(t *testing.T) {
+	cfg := TaggedConfig{}
+	rootCmd := cmd.NewRootCmd(&cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {},
+	}, &cfg)
+
+	bindings := rootCmd.EnvBindings()
+	assert.Equal(t, []string{"LOG_LEVEL"}, bindings["log_level"])
+	assert.Equal(t, []string{"SERVER_OPTS_LISTEN_PORT"}, bindings["server_opts.listen_port"])
+	assert.Equal(t, []string{"SERVER_OPTS_HOST_NAME"}, bindings["server_opts.host_name"])
+
+	os.Setenv("SERVER_OPTS_LISTEN_PORT", "8080")
+	defer os.Unsetenv("SERVER_OPTS_LISTEN_PORT")
+
+	rootCmd.SetArgs([]string{})
+	err := rootCmd.Execute()
+
+	if verr, ok := err.(*cmd.ValidationError); assert.True(t, ok) {
+		assert.Equal(t, &cmd.FieldError{
+			Key:     "server_opts.listen_port",
+			Env:     []string{"SERVER_OPTS_LISTEN_PORT"},
+			Message: "value '8080' failed on 'max=1000'",
+		}, verr.Errors[0])
+	}
+}
+
+type ExcludedConfig struct {
+	Name     string
+	Internal string `json:"-"`
+	Cache    string `yaml:"-"`
+	Token    string `mapstructure:"-"`
+	Note     string `json:",omitempty"`
+}
+
+func TestExcludedKeys(t *testing.T) {
+	data, err := generator.Marshal(ExcludedConfig{
+		Name:     "test",
+		Internal: "internal",
+		Cache:    "cache",
+		Token:    "token",
+	}, "config.yaml")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "name: test\n", string(data))
+	}
+
+	data, err = generator.Marshal(ExcludedConfig{Name: "test", Note: "note"}, "config.json")
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, `{"name":"test","note":"note"}`, string(data))
+	}
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	err = os.WriteFile(file, []byte("name: test\ninternal: a\ncache: b\ntoken: c\n"), 0644)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cfg := ExcludedConfig{}
+	rootCmd := cmd.NewRootCmd(&cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {},
+	}, &cfg)
+
+	assert.Equal(t, map[string][]string{
+		"name": {"NAME"},
+		"note": {"NOTE"},
+	}, rootCmd.EnvBindings())
+
+	rootCmd.SetArgs([]string{"--config", file})
+	if assert.NoError(t, rootCmd.Execute()) {
+		assert.Equal(t, ExcludedConfig{Name: "test"}, cfg)
+	}
+
+	cfg = ExcludedConfig{}
+	rootCmd = cmd.NewRootCmd(&cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {},
+	}, &cfg)
+
+	rootCmd.SilenceErrors = true
+	rootCmd.SilenceUsage = true
+
+	rootCmd.SetArgs([]string{"--config", file, "--strict"})
+	err = rootCmd.Execute()
+
+	var keysErr *cmd.UnknownKeysError
+	if assert.ErrorAs(t, err, &keysErr) && assert.Len(t, keysErr.Errors, 3) {
+		assert.Equal(t, "internal", keysErr.Errors[0].Key)
+		assert.Equal(t, "cache", keysErr.Errors[1].Key)
+		assert.Equal(t, "token", keysErr.Errors[2].Key)
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright © 2021 Dirk Lembke <dirk@lembke.nz>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"strings"
+)
+
+// EnvNaming creates the env var name for a config field without env tag
+// from the key names of its path
+type EnvNaming func(path []string) string
+
+// ScreamingSnake creates names like OPTIONS_PATH, it's the default
+func ScreamingSnake(path []string) string {
+	return strings.ToUpper(Snake(path))
+}
+
+// Snake creates names like options_path
+func Snake(path []string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.Join(path, "_"), "-", "_"))
+}
+
+type envNamer struct {
+	prefix string
+	naming EnvNaming
+}
+
+func newEnvNamer(prefix string, naming EnvNaming) envNamer {
+	if prefix != "" && !strings.HasSuffix(prefix, "_") {
+		prefix += "_"
+	}
+
+	if naming == nil {
+		naming = ScreamingSnake
+	}
+
+	return envNamer{
+		prefix: prefix,
+		naming: naming,
+	}
+}
+
+// name returns the env var name of a field without env tag
+func (n envNamer) name(path []string) string {
+	return n.prefix + n.naming(path)
+}
+
+// tagged returns the env var name of a field with env tag
+func (n envNamer) tagged(envpath []string) string {
+	return n.prefix + strings.Join(envpath, "_")
+}
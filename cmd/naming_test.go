@@ -0,0 +1,133 @@
+package cmd_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zauberhaus/42/cmd"
+)
+
+type NamingConfig struct {
+	Name     string
+	Database NamingDatabase
+	Server   NamingServer `env:"SRV"`
+}
+
+type NamingDatabase struct {
+	Host string
+}
+
+type NamingServer struct {
+	Port int `env:"PORT"`
+}
+
+func TestEnvPrefix(t *testing.T) {
+	cfg := NamingConfig{}
+
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	err := ioutil.WriteFile(filename, []byte("database:\n  host: file\n"), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	rootCmd := cmd.NewRootCmd(
+		&cobra.Command{Use: t.Name(),
+			Short: "Test program",
+			Run: func(c *cobra.Command, args []string) {
+				assert.Equal(t, "test", cfg.Name)
+				assert.Equal(t, "file", cfg.Database.Host)
+				assert.Equal(t, 8080, cfg.Server.Port)
+			},
+		}, &cfg, cmd.WithEnvPrefix("MYAPP"),
+	)
+
+	assert.Equal(t, map[string][]string{
+		"name":          {"MYAPP_NAME"},
+		"database.host": {"MYAPP_DATABASE_HOST"},
+		"server.port":   {"MYAPP_SRV_PORT"},
+	}, rootCmd.EnvBindings())
+
+	os.Setenv("MYAPP_NAME", "test")
+	os.Setenv("MYAPP_SRV_PORT", "8080")
+	os.Setenv("MYAPP_CONFIG", filename)
+	os.Setenv("NAME", "wrong")
+	os.Setenv("CONFIG", "./testdata/config.yaml")
+	defer os.Unsetenv("MYAPP_NAME")
+	defer os.Unsetenv("MYAPP_SRV_PORT")
+	defer os.Unsetenv("MYAPP_CONFIG")
+	defer os.Unsetenv("NAME")
+	defer os.Unsetenv("CONFIG")
+
+	rootCmd.SetArgs([]string{})
+	err = rootCmd.Execute()
+	assert.NoError(t, err)
+}
+
+func TestEnvPrefixFlag(t *testing.T) {
+	cfg := NamingConfig{}
+
+	rootCmd := cmd.NewRootCmd(
+		&cobra.Command{Use: t.Name(),
+			Short: "Test program",
+			Run: func(c *cobra.Command, args []string) {
+				assert.Equal(t, "flag", cfg.Name)
+			},
+		}, &cfg, cmd.WithEnvPrefix("MYAPP_"),
+	)
+
+	rootCmd.WithInit(func(rc *cmd.RootCommand) {
+		rc.Flags().StringP("name", "n", "", "Name")
+		rc.BindCmdFlag(rc.Flags(), "name")
+	})
+
+	rootCmd.SetArgs([]string{"-n", "flag"})
+	err := rootCmd.Execute()
+	assert.NoError(t, err)
+}
+
+func TestEnvNaming(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		naming cmd.EnvNaming
+		wanted map[string][]string
+	}{
+		"snake": {
+			naming: cmd.Snake,
+			wanted: map[string][]string{
+				"name":          {"app_name"},
+				"database.host": {"app_database_host"},
+				"server.port":   {"app_SRV_PORT"},
+			},
+		},
+		"custom": {
+			naming: func(path []string) string {
+				return strings.ToUpper(strings.Join(path, "__"))
+			},
+			wanted: map[string][]string{
+				"name":          {"app_NAME"},
+				"database.host": {"app_DATABASE__HOST"},
+				"server.port":   {"app_SRV_PORT"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+
+		t.Run(t.Name()+"_"+name, func(t *testing.T) {
+			rootCmd := cmd.NewRootCmd(
+				&cobra.Command{Use: t.Name(),
+					Short: "Test program",
+				}, &NamingConfig{}, cmd.WithEnvPrefix("app"), cmd.WithEnvNaming(test.naming),
+			)
+
+			assert.Equal(t, test.wanted, rootCmd.EnvBindings())
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package cmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zauberhaus/42/cmd"
+)
+
+type ProfileConfig struct {
+	Host    string
+	Port    int
+	Options ProfileOptions
+}
+
+type ProfileOptions struct {
+	Debug bool
+	Name  string
+}
+
+const profileBase = `host: localhost
+port: 80
+options:
+  debug: true
+  name: base
+profiles:
+  prod:
+    host: example.com
+    options:
+      debug: false
+`
+
+func TestProfile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.yaml")
+
+	if !assert.NoError(t, os.WriteFile(file, []byte(profileBase), 0600)) {
+		return
+	}
+
+	if !assert.NoError(t, os.WriteFile(filepath.Join(dir, "app.staging.yaml"), []byte("port: 8080\n"), 0600)) {
+		return
+	}
+
+	tests := map[string]struct {
+		args   []string
+		env    string
+		wanted ProfileConfig
+	}{
+		"none": {
+			args:   []string{"--config", file},
+			wanted: ProfileConfig{Host: "localhost", Port: 80, Options: ProfileOptions{Debug: true, Name: "base"}},
+		},
+		"section": {
+			args:   []string{"--config", file, "--profile", "prod"},
+			wanted: ProfileConfig{Host: "example.com", Port: 80, Options: ProfileOptions{Debug: false, Name: "base"}},
+		},
+		"file": {
+			args:   []string{"--config", file, "--profile", "staging"},
+			wanted: ProfileConfig{Host: "localhost", Port: 8080, Options: ProfileOptions{Debug: true, Name: "base"}},
+		},
+		"env": {
+			args:   []string{"--config", file},
+			env:    "prod",
+			wanted: ProfileConfig{Host: "example.com", Port: 80, Options: ProfileOptions{Debug: false, Name: "base"}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if tt.env != "" {
+				os.Setenv("APP_PROFILE", tt.env)
+				defer os.Unsetenv("APP_PROFILE")
+			}
+
+			cfg := ProfileConfig{}
+			rootCmd := cmd.NewRootCmd(&cobra.Command{
+				Use: "app",
+				Run: func(cmd *cobra.Command, args []string) {},
+			}, &cfg, cmd.WithEnvPrefix("APP"))
+
+			rootCmd.SetArgs(tt.args)
+
+			if assert.NoError(t, rootCmd.Execute()) {
+				assert.Equal(t, tt.wanted, cfg)
+				assert.False(t, rootCmd.Viper().IsSet("profiles"))
+			}
+		})
+	}
+}
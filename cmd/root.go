@@ -17,17 +17,19 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"reflect"
 	"strings"
-	"unsafe"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/mcuadros/go-defaults"
 	"github.com/zauberhaus/42/logger"
 
-	"github.com/fsnotify/fsnotify"
-	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/thediveo/enumflag"
@@ -35,27 +37,63 @@ import (
 
 type AddFunc func(*RootCommand)
 type InitFunc func(*RootCommand)
+type ConfigChangeFunc func(old interface{}, new interface{})
+
+// Option configures a RootCommand before the env vars and flags are bound
+type Option func(*RootCommand)
 
 type RootCommand struct {
 	cobra.Command
 	configFile        string
 	defaultConfigFile string
+	profile           string
+	envFiles          []string
+	dotenv            map[string]bool
 	version           *Version
 
-	config   interface{}
-	logLevel logger.Level
+	config    atomic.Value
+	logLevel  logger.Level
+	viper     *viper.Viper
+	bindings  *bindings
+	envPrefix string
+	envNaming EnvNaming
+	bindErr   error
+	flagErr   error
+	preRun    bool
+	strict    bool
+
+	execSecrets bool
+
+	gracePeriod time.Duration
+	stopTimeout time.Duration
+	services    []Service
+
+	mutex          sync.RWMutex
+	subscribers    []ConfigChangeFunc
+	keySubscribers []keySubscriber
+	layers         []*configLayer
+	watcher        *configWatcher
 }
 
-func NewRootCmd(cmd *cobra.Command, config interface{}) *RootCommand {
+func NewRootCmd(cmd *cobra.Command, config interface{}, opts ...Option) *RootCommand {
 	var rootCmd *RootCommand
 
 	rootCmd = &RootCommand{
 		Command:  *cmd,
 		logLevel: 0,
-		config:   config,
+		viper:    viper.New(),
+		bindings: newBindings(),
+
+		gracePeriod: DefaultGracePeriod,
+		stopTimeout: DefaultStopTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(rootCmd)
 	}
 
 	defaults.SetDefaults(config)
+	rootCmd.config.Store(config)
 
 	rootCmd.init()
 
@@ -66,8 +104,26 @@ func (r *RootCommand) SetDefaultConfigFile(configFile string) {
 	r.defaultConfigFile = configFile
 }
 
+// WithEnvPrefix sets a prefix like MYAPP_ for all env vars including CONFIG
+func WithEnvPrefix(prefix string) Option {
+	return func(r *RootCommand) {
+		r.envPrefix = prefix
+	}
+}
+
+// WithEnvNaming sets the naming strategy for env vars of fields without env tag
+func WithEnvNaming(naming EnvNaming) Option {
+	return func(r *RootCommand) {
+		r.envNaming = naming
+	}
+}
+
 func (r *RootCommand) SetVersion(version *Version) {
 	r.version = version
+
+	if r.Version != "" {
+		r.Version = version.Short()
+	}
 }
 
 func (r *RootCommand) WithInit(init InitFunc) {
@@ -80,14 +136,93 @@ func (r *RootCommand) WithSubCommands(commands ...AddFunc) {
 	}
 }
 
+// OnConfigChange registers a callback, which is called with the old and the new
+// config after the config file was changed and reloaded
+func (r *RootCommand) OnConfigChange(f ConfigChangeFunc) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.subscribers = append(r.subscribers, f)
+}
+
+// Execute runs the command and returns a ConfigError, ValidationError,
+// UsageError or RuntimeError, cmd.Context() is cancelled by SIGINT or SIGTERM
 func (r *RootCommand) Execute() error {
-	return r.Command.Execute()
+	_, err := r.ExecuteContextC(context.Background())
+	return err
+}
+
+// ExecuteC runs the command like Execute and returns the executed command
+func (r *RootCommand) ExecuteC() (*cobra.Command, error) {
+	return r.ExecuteContextC(context.Background())
+}
+
+// ExecuteContext runs the command like Execute with a child context of ctx
+func (r *RootCommand) ExecuteContext(ctx context.Context) error {
+	_, err := r.ExecuteContextC(ctx)
+	return err
+}
+
+// ExecuteContextC runs the command like ExecuteContext and returns the executed
+// command, config files are watched and env vars loaded from env files are set
+// until it returns
+func (r *RootCommand) ExecuteContextC(ctx context.Context) (*cobra.Command, error) {
+	ctx, stop := r.signalContext(ctx)
+	defer stop()
+	defer r.unloadEnvFiles()
+	defer r.unwatchConfig()
+
+	r.preRun = false
+	cmd, err := r.Command.ExecuteContextC(ctx)
+
+	return cmd, r.wrapError(err)
+}
+
+// Main runs the command, logs an error with the usage for usage errors and
+// exits with the ExitCode of the error
+func (r *RootCommand) Main() {
+	r.SilenceErrors = true
+	r.SilenceUsage = true
+
+	cmd, err := r.ExecuteC()
+	if err != nil {
+		logger.Error(err)
+
+		var usageErr *UsageError
+		if errors.As(err, &usageErr) && cmd != nil {
+			cmd.PrintErrln(cmd.UsageString())
+		}
+	}
+
+	os.Exit(ExitCode(err))
 }
 
 func (r *RootCommand) init() {
 	old := r.PersistentPreRunE
 	r.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
-		r.initializeConfig(cmd)
+		r.preRun = true
+
+		if err := checkRequiredFlags(cmd); err != nil {
+			return &UsageError{Err: err}
+		}
+
+		if r.bindErr != nil {
+			return &ConfigError{Err: r.bindErr}
+		}
+
+		if r.flagErr != nil {
+			return &ConfigError{Err: r.flagErr}
+		}
+
+		if err := r.initializeConfig(cmd); err != nil {
+			return &ConfigError{Err: err}
+		}
+
+		if _, ok := cmd.Annotations[skipValidation]; !ok {
+			if err := r.validateConfig(r.GetConfig()); err != nil {
+				return err
+			}
+		}
 
 		if r.logLevel != 0 {
 			logger.SetLogLevel(r.logLevel)
@@ -100,6 +235,10 @@ func (r *RootCommand) init() {
 		return nil
 	}
 
+	r.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return &UsageError{Err: err}
+	})
+
 	if r.defaultConfigFile == "" {
 		r.defaultConfigFile = r.Command.Name()
 	}
@@ -107,67 +246,132 @@ func (r *RootCommand) init() {
 	loglevelIds := logger.GetLogger().GetLevelMap()
 	loglevelNames := logger.GetLogger().GetLevelNames()
 
-	r.PersistentFlags().StringVar(&r.configFile, "config", "", "Config file (default is $HOME/"+r.configFile+".yaml)")
+	r.PersistentFlags().StringVar(&r.configFile, "config", "", "Config file, merged over /etc, XDG, $HOME and working dir config files (default is $HOME/"+r.defaultConfigFile+".yaml)")
+	r.PersistentFlags().StringSliceVar(&r.envFiles, "env-file", nil, "Env files, which don't override the environment (default is "+dotenvFile+" next to the config file)")
+	r.PersistentFlags().BoolVar(&r.strict, "strict", false, "Fail on config file keys, which aren't part of the config")
+	r.PersistentFlags().StringVar(&r.profile, "profile", "", "Config profile, merged over the base config from a profiles section or <config>.<profile>.* files")
 	r.PersistentFlags().VarP(
 		enumflag.New(&r.logLevel, "level", loglevelIds, enumflag.EnumCaseInsensitive),
 		"log", "l",
 		"Log level ("+strings.Join(loglevelNames, ", ")+")")
 
-	AutoBindEnv(r.config)
+	r.bindErr = r.AutoBindEnv(r.GetConfig())
+
+	if err := r.AutoBindFlags(r.PersistentFlags(), r.GetConfig()); err != nil {
+		r.flagErr = fmt.Errorf("Bind flags: %v", err)
+	}
 }
 
 func (r *RootCommand) initializeConfig(cmd *cobra.Command) error {
-	if r.configFile != "" {
-		viper.SetConfigFile(r.configFile)
-	} else {
-		tmp := os.Getenv("CONFIG")
-		if tmp != "" {
-			r.configFile = tmp
-			viper.SetConfigFile(r.configFile)
-		} else {
-			home, err := homedir.Dir()
-			if err != nil {
-				logger.Error("Get homedir: %v", err)
-				os.Exit(1)
-			}
+	if err := r.loadEnvFiles(); err != nil {
+		return err
+	}
 
-			viper.AddConfigPath(home)
-			viper.SetConfigName(r.defaultConfigFile)
-		}
+	files, err := r.readConfig()
+	if err != nil {
+		return err
 	}
 
-	if err := viper.ReadInConfig(); err == nil {
-		logger.Info(fmt.Sprintf("Using config file: %v", viper.ConfigFileUsed()))
+	if err := r.checkKeys(); err != nil {
+		return err
+	}
 
-		viper.WatchConfig()
-		viper.OnConfigChange(func(e fsnotify.Event) {
-			logger.Info(fmt.Sprintf("Config file changed: %v", e.Name))
-		})
-	} else {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return err
-		}
+	if len(files) > 0 {
+		logger.Info(fmt.Sprintf("Using config files: %v", strings.Join(files, ", ")))
+		r.watchConfig(files)
+	}
+
+	err = unmarshal(r.viper, r.GetConfig(), r.execSecrets)
+	if err != nil {
+		return fmt.Errorf("Unmarshal config file: %v", err)
 	}
 
-	err := viper.Unmarshal(r.config)
+	return nil
+}
+
+func (r *RootCommand) reloadConfig() error {
+	if err := r.checkKeys(); err != nil {
+		return err
+	}
+
+	config, err := newConfig(reflect.TypeOf(r.GetConfig()))
+	if err != nil {
+		return err
+	}
+
+	err = unmarshal(r.viper, config, r.execSecrets)
 	if err != nil {
 		return fmt.Errorf("Unmarshal config file: %v", err)
 	}
 
+	if err := r.validateConfig(config); err != nil {
+		return err
+	}
+
+	old := r.config.Swap(config)
+
+	r.mutex.RLock()
+	subscribers := append([]ConfigChangeFunc{}, r.subscribers...)
+	r.mutex.RUnlock()
+
+	for _, f := range subscribers {
+		f(old, config)
+	}
+
+	r.notifyKeys(old, config)
+
 	return nil
 }
 
+func newConfig(t reflect.Type) (interface{}, error) {
+	if t.Kind() != reflect.Pointer {
+		return nil, fmt.Errorf("Config is not a pointer: %v", t)
+	}
+
+	config := reflect.New(t.Elem()).Interface()
+	defaults.SetDefaults(config)
+
+	return config, nil
+}
+
+// Profile returns the config profile set by --profile or $<PREFIX>PROFILE
+func (r *RootCommand) Profile() string {
+	if r.profile != "" {
+		return r.profile
+	}
+
+	return os.Getenv(newEnvNamer(r.envPrefix, nil).tagged([]string{"PROFILE"}))
+}
+
+// Viper returns the viper instance of the command
+func (r *RootCommand) Viper() *viper.Viper {
+	return r.viper
+}
+
 func (r *RootCommand) GetVersion() *Version {
+	if r.version == nil {
+		r.version = NewVersion("", "", "", "")
+	}
+
 	return r.version
 }
 
+// GetConfig returns the current config, a reload replaces it with a new instance
 func (r *RootCommand) GetConfig() interface{} {
-	return r.config
+	return r.config.Load()
+}
+
+// Bindings returns the env bindings of the config
+func (r *RootCommand) Bindings() []*EnvBinding {
+	return r.bindings.list()
 }
 
+// EnvBindings returns the env vars bound to each config key
 func (r *RootCommand) EnvBindings() map[string][]string {
-	f := reflect.ValueOf(viper.GetViper()).Elem().FieldByName("env")
-	rf := reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem()
-	i := rf.Interface()
-	return i.(map[string][]string)
+	result := map[string][]string{}
+	for _, b := range r.Bindings() {
+		result[b.Key] = append(result[b.Key], b.Env)
+	}
+
+	return result
 }
@@ -21,7 +21,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
@@ -30,16 +32,16 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-var (
-	config Config
-)
-
 type Config struct {
 	Name  string
 	Value int
 }
 
 func TestRunYamlConfigFile(t *testing.T) {
+	t.Parallel()
+
+	cfg := Config{}
+
 	expected, err := readConfig("./testdata/config.yaml")
 	assert.NoError(t, err)
 
@@ -47,10 +49,10 @@ func TestRunYamlConfigFile(t *testing.T) {
 		&cobra.Command{Use: t.Name(),
 			Short: "Test program",
 			Run: func(cmd *cobra.Command, args []string) {
-				assert.Equal(t, expected.Name, config.Name)
-				assert.Equal(t, expected.Value, config.Value)
+				assert.Equal(t, expected.Name, cfg.Name)
+				assert.Equal(t, expected.Value, cfg.Value)
 			},
-		}, &config,
+		}, &cfg,
 	)
 
 	rootCmd.SetArgs([]string{"--config", "./testdata/config.yaml"})
@@ -60,6 +62,10 @@ func TestRunYamlConfigFile(t *testing.T) {
 }
 
 func TestRunTomlConfigFile(t *testing.T) {
+	t.Parallel()
+
+	cfg := Config{}
+
 	expected, err := readConfig("./testdata/config.yaml")
 	assert.NoError(t, err)
 
@@ -67,10 +73,10 @@ func TestRunTomlConfigFile(t *testing.T) {
 		&cobra.Command{Use: t.Name(),
 			Short: "Test program",
 			Run: func(cmd *cobra.Command, args []string) {
-				assert.Equal(t, expected.Name, config.Name)
-				assert.Equal(t, expected.Value, config.Value)
+				assert.Equal(t, expected.Name, cfg.Name)
+				assert.Equal(t, expected.Value, cfg.Value)
 			},
-		}, &config,
+		}, &cfg,
 	)
 
 	rootCmd.SetArgs([]string{"--config", "./testdata/config.toml"})
@@ -80,6 +86,10 @@ func TestRunTomlConfigFile(t *testing.T) {
 }
 
 func TestRunJsonConfigFile(t *testing.T) {
+	t.Parallel()
+
+	cfg := Config{}
+
 	expected, err := readConfig("./testdata/config.yaml")
 	assert.NoError(t, err)
 
@@ -87,10 +97,10 @@ func TestRunJsonConfigFile(t *testing.T) {
 		&cobra.Command{Use: t.Name(),
 			Short: "Test program",
 			Run: func(cmd *cobra.Command, args []string) {
-				assert.Equal(t, expected.Name, config.Name)
-				assert.Equal(t, expected.Value, config.Value)
+				assert.Equal(t, expected.Name, cfg.Name)
+				assert.Equal(t, expected.Value, cfg.Value)
 			},
-		}, &config,
+		}, &cfg,
 	)
 
 	rootCmd.SetArgs([]string{"--config", "./testdata/config.json"})
@@ -100,6 +110,8 @@ func TestRunJsonConfigFile(t *testing.T) {
 }
 
 func TestRunEnvConfigFile(t *testing.T) {
+	cfg := Config{}
+
 	expected, err := readConfig("./testdata/config.yaml")
 	assert.NoError(t, err)
 
@@ -107,10 +119,10 @@ func TestRunEnvConfigFile(t *testing.T) {
 		&cobra.Command{Use: t.Name(),
 			Short: "Test program",
 			Run: func(cmd *cobra.Command, args []string) {
-				assert.Equal(t, expected.Name, config.Name)
-				assert.Equal(t, expected.Value, config.Value)
+				assert.Equal(t, expected.Name, cfg.Name)
+				assert.Equal(t, expected.Value, cfg.Value)
 			},
-		}, &config,
+		}, &cfg,
 	)
 
 	os.Setenv("CONFIG", "./testdata/config.yaml")
@@ -122,6 +134,10 @@ func TestRunEnvConfigFile(t *testing.T) {
 }
 
 func TestRunFlags(t *testing.T) {
+	t.Parallel()
+
+	cfg := Config{}
+
 	name := t.Name()
 	value := len(t.Name())
 
@@ -129,18 +145,18 @@ func TestRunFlags(t *testing.T) {
 		&cobra.Command{Use: t.Name(),
 			Short: "Test program",
 			Run: func(cmd *cobra.Command, args []string) {
-				assert.Equal(t, name, config.Name)
-				assert.Equal(t, value, config.Value)
+				assert.Equal(t, name, cfg.Name)
+				assert.Equal(t, value, cfg.Value)
 			},
-		}, &config,
+		}, &cfg,
 	)
 
 	rootCmd.WithInit(func(rc *cmd.RootCommand) {
 		rc.Flags().StringP("name", "n", "", "Name")
-		cmd.BindCmdFlag(rc.Flags(), "name")
+		rc.BindCmdFlag(rc.Flags(), "name")
 
 		rc.Flags().IntP("value", "v", 0, "Value")
-		cmd.BindCmdFlag(rc.Flags(), "value")
+		rc.BindCmdFlag(rc.Flags(), "value")
 	})
 
 	rootCmd.SetArgs([]string{"-n", name, "-v", fmt.Sprintf("%v", value)})
@@ -149,6 +165,8 @@ func TestRunFlags(t *testing.T) {
 }
 
 func TestRunEnv(t *testing.T) {
+	cfg := Config{}
+
 	name := t.Name()
 	value := len(t.Name())
 
@@ -156,18 +174,18 @@ func TestRunEnv(t *testing.T) {
 		&cobra.Command{Use: t.Name(),
 			Short: "Test program",
 			Run: func(cmd *cobra.Command, args []string) {
-				assert.Equal(t, name, config.Name)
-				assert.Equal(t, value, config.Value)
+				assert.Equal(t, name, cfg.Name)
+				assert.Equal(t, value, cfg.Value)
 			},
-		}, &config,
+		}, &cfg,
 	)
 
 	rootCmd.WithInit(func(rc *cmd.RootCommand) {
 		rc.Flags().StringP("name", "n", "", "Name")
-		cmd.BindCmdFlag(rc.Flags(), "name")
+		rc.BindCmdFlag(rc.Flags(), "name")
 
 		rc.Flags().IntP("value", "v", 0, "Value")
-		cmd.BindCmdFlag(rc.Flags(), "value")
+		rc.BindCmdFlag(rc.Flags(), "value")
 	})
 
 	os.Setenv("NAME", name)
@@ -181,14 +199,18 @@ func TestRunEnv(t *testing.T) {
 }
 
 func TestRunSubCommand(t *testing.T) {
+	t.Parallel()
+
+	cfg := Config{}
+
 	rootCmd := cmd.NewRootCmd(
 		&cobra.Command{Use: t.Name(),
 			Short: "Test program",
 			Run: func(cmd *cobra.Command, args []string) {
-				assert.Equal(t, t.Name(), config.Name)
-				assert.Equal(t, 7365, config.Value)
+				assert.Equal(t, t.Name(), cfg.Name)
+				assert.Equal(t, 7365, cfg.Value)
 			},
-		}, &config,
+		}, &cfg,
 	)
 
 	version := cmd.NewVersion("today", "123456", "v1.1.1", "dirty")
@@ -196,10 +218,10 @@ func TestRunSubCommand(t *testing.T) {
 
 	rootCmd.WithInit(func(rc *cmd.RootCommand) {
 		rc.Flags().StringP("name", "n", "", "Name")
-		cmd.BindCmdFlag(rc.Flags(), "name")
+		rc.BindCmdFlag(rc.Flags(), "name")
 
 		rc.Flags().IntP("value", "v", 0, "Value")
-		cmd.BindCmdFlag(rc.Flags(), "value")
+		rc.BindCmdFlag(rc.Flags(), "value")
 	})
 
 	rootCmd.WithSubCommands(func(rc *cmd.RootCommand) {
@@ -239,13 +261,15 @@ func TestRunSubCommand(t *testing.T) {
 }
 
 func TestLogLevel(t *testing.T) {
+	cfg := Config{}
+
 	rootCmd := cmd.NewRootCmd(
 		&cobra.Command{Use: t.Name(),
 			Short: "Test program",
 			Run: func(cmd *cobra.Command, args []string) {
 				assert.Equal(t, int8(-1), logger.GetLogger().GetLevel())
 			},
-		}, &config,
+		}, &cfg,
 	)
 
 	rootCmd.SetArgs([]string{"-l", "debug"})
@@ -254,6 +278,103 @@ func TestLogLevel(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestConfigChange(t *testing.T) {
+	t.Parallel()
+
+	cfg := Config{}
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "config.yaml")
+
+	err := ioutil.WriteFile(filename, []byte("name: old\nvalue: 1\n"), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	changed := make(chan [2]*Config, 1)
+
+	var rootCmd *cmd.RootCommand
+	rootCmd = cmd.NewRootCmd(
+		&cobra.Command{Use: t.Name(),
+			Short: "Test program",
+			Run: func(cmd *cobra.Command, args []string) {
+				assert.Equal(t, "old", cfg.Name)
+				assert.Equal(t, 1, cfg.Value)
+
+				err := ioutil.WriteFile(filename, []byte("name: new\nvalue: 2\n"), 0600)
+				assert.NoError(t, err)
+
+				select {
+				case c := <-changed:
+					assert.Equal(t, "old", c[0].Name)
+					assert.Equal(t, 1, c[0].Value)
+					assert.Equal(t, "new", c[1].Name)
+					assert.Equal(t, 2, c[1].Value)
+					assert.Equal(t, c[1], rootCmd.GetConfig())
+				case <-time.After(5 * time.Second):
+					t.Error("Config change not received")
+				}
+			},
+		}, &cfg,
+	)
+
+	rootCmd.OnConfigChange(func(old interface{}, new interface{}) {
+		select {
+		case changed <- [2]*Config{old.(*Config), new.(*Config)}:
+		default:
+		}
+	})
+
+	rootCmd.SetArgs([]string{"--config", filename})
+
+	err = rootCmd.Execute()
+	assert.NoError(t, err)
+}
+
+func TestConfigChangeAfterExecute(t *testing.T) {
+	cfg := Config{}
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "config.yaml")
+
+	err := ioutil.WriteFile(filename, []byte("name: old\nvalue: 1\n"), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	changed := make(chan struct{}, 1)
+
+	rootCmd := cmd.NewRootCmd(
+		&cobra.Command{Use: t.Name(),
+			Short: "Test program",
+			Run:   func(cmd *cobra.Command, args []string) {},
+		}, &cfg,
+	)
+
+	rootCmd.OnConfigChange(func(old interface{}, new interface{}) {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+
+	rootCmd.SetArgs([]string{"--config", filename})
+	if !assert.NoError(t, rootCmd.Execute()) {
+		return
+	}
+
+	err = ioutil.WriteFile(filename, []byte("name: new\nvalue: 2\n"), 0600)
+	assert.NoError(t, err)
+
+	select {
+	case <-changed:
+		t.Error("Config reloaded after Execute returned")
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	assert.Equal(t, "old", cfg.Name)
+}
+
 func readConfig(file string) (*Config, error) {
 	var result Config
 
@@ -0,0 +1,95 @@
+/*
+Copyright © 2021 Dirk Lembke <dirk@lembke.nz>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+)
+
+const (
+	fileRef = "file://"
+	envRef  = "env://"
+	execRef = "exec://"
+)
+
+// SetExecSecrets allows exec:// references in secret fields, which run the
+// command and use its output, they are rejected by default
+func (r *RootCommand) SetExecSecrets(allow bool) {
+	r.execSecrets = allow
+}
+
+// ResolveSecret returns the value of a file:// or env:// reference, exec://
+// references are only executed if allowExec is true, other values are
+// returned unchanged
+func ResolveSecret(value string, allowExec bool) (string, error) {
+	switch {
+	case strings.HasPrefix(value, fileRef):
+		data, err := ioutil.ReadFile(strings.TrimPrefix(value, fileRef))
+		if err != nil {
+			return "", err
+		}
+
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case strings.HasPrefix(value, envRef):
+		name := strings.TrimPrefix(value, envRef)
+
+		val, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("Environment variable not set: %v", name)
+		}
+
+		return val, nil
+	case strings.HasPrefix(value, execRef):
+		if !allowExec {
+			return "", fmt.Errorf("Exec references are disabled: %v", value)
+		}
+
+		args := strings.Fields(strings.TrimPrefix(value, execRef))
+		if len(args) == 0 {
+			return "", fmt.Errorf("Missing command: %v", value)
+		}
+
+		out, err := exec.Command(args[0], args[1:]...).Output()
+		if err != nil {
+			return "", fmt.Errorf("Execute %v: %v", args[0], err)
+		}
+
+		return strings.TrimRight(string(out), "\r\n"), nil
+	default:
+		return value, nil
+	}
+}
+
+// resolveSecretField resolves the reference of a string value for a secret
+// field of type t, other values are returned unchanged
+func resolveSecretField(t reflect.Type, data interface{}, allowExec bool) (interface{}, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	value, ok := data.(string)
+	if !ok || t.Kind() != reflect.String {
+		return data, nil
+	}
+
+	return ResolveSecret(value, allowExec)
+}
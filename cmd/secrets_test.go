@@ -0,0 +1,173 @@
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zauberhaus/42/cmd"
+)
+
+type SecretRefConfig struct {
+	User     string
+	Password string `secret:"true"`
+	Token    string `secret:"true"`
+	Port     int
+}
+
+type SecretPlainConfig struct {
+	Name     string
+	Endpoint url.URL
+	Command  string
+	Token    string `secret:"true"`
+}
+
+func TestResolveSecret(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "password")
+
+	err := ioutil.WriteFile(filename, []byte("geheim\n"), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	os.Setenv("SECRET_TEST", "value")
+	defer os.Unsetenv("SECRET_TEST")
+
+	tests := map[string]string{
+		"plain":              "plain",
+		"file://" + filename: "geheim",
+		"env://SECRET_TEST":  "value",
+		"exec://echo test":   "test",
+	}
+
+	for ref, wanted := range tests {
+		value, err := cmd.ResolveSecret(ref, true)
+		if assert.NoError(t, err) {
+			assert.Equal(t, wanted, value)
+		}
+	}
+
+	_, err = cmd.ResolveSecret("exec://echo test", false)
+	assert.EqualError(t, err, "Exec references are disabled: exec://echo test")
+
+	_, err = cmd.ResolveSecret("env://SECRET_TEST_MISSING", false)
+	assert.EqualError(t, err, "Environment variable not set: SECRET_TEST_MISSING")
+
+	_, err = cmd.ResolveSecret("file://"+filepath.Join(dir, "missing"), false)
+	assert.Error(t, err)
+}
+
+func TestSecretConfig(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "config.yaml")
+	password := filepath.Join(dir, "password")
+
+	err := ioutil.WriteFile(password, []byte("geheim\n"), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	err = ioutil.WriteFile(filename, []byte("user: admin\npassword: file://"+password+"\nport: 8443\n"), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	os.Setenv("TOKEN", "exec://echo token")
+	defer os.Unsetenv("TOKEN")
+
+	cfg := SecretRefConfig{}
+
+	rootCmd := cmd.NewRootCmd(
+		&cobra.Command{Use: t.Name(),
+			Short: "Test program",
+		}, &cfg,
+	)
+
+	rootCmd.SetExecSecrets(true)
+	rootCmd.WithSubCommands(cmd.ConfigCommand)
+
+	output := &bytes.Buffer{}
+	rootCmd.SetOut(output)
+	rootCmd.SetArgs([]string{"config", "show", "--config", filename})
+
+	err = rootCmd.Execute()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "admin", cfg.User)
+		assert.Equal(t, "geheim", cfg.Password)
+		assert.Equal(t, "token", cfg.Token)
+		assert.Equal(t, 8443, cfg.Port)
+
+		assert.Equal(t, "user: admin\npassword: '******'\ntoken: '******'\nport: 8443\n", output.String())
+	}
+}
+
+func TestSecretPlainFields(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "config.yaml")
+	hostname := filepath.Join(dir, "hostname")
+
+	err := ioutil.WriteFile(hostname, []byte("localhost\n"), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	err = ioutil.WriteFile(filename, []byte("name: file://"+hostname+"\nendpoint: file://"+hostname+"\ncommand: exec://echo test\n"), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cfg := SecretPlainConfig{}
+
+	rootCmd := cmd.NewRootCmd(
+		&cobra.Command{Use: t.Name(),
+			Short: "Test program",
+			Run: func(c *cobra.Command, args []string) {
+				assert.Equal(t, "file://"+hostname, cfg.Name)
+				assert.Equal(t, "file://"+hostname, cfg.Endpoint.String())
+				assert.Equal(t, "exec://echo test", cfg.Command)
+			},
+		}, &cfg,
+	)
+
+	rootCmd.SetArgs([]string{"--config", filename})
+	err = rootCmd.Execute()
+	assert.NoError(t, err)
+}
+
+func TestSecretExecDisabled(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "config.yaml")
+
+	err := ioutil.WriteFile(filename, []byte("token: exec://echo token\n"), 0600)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cfg := SecretPlainConfig{}
+
+	rootCmd := cmd.NewRootCmd(
+		&cobra.Command{Use: t.Name(),
+			Short: "Test program",
+			Run: func(c *cobra.Command, args []string) {
+				assert.Fail(t, "Command shouldn't run")
+			},
+		}, &cfg,
+	)
+
+	rootCmd.SilenceErrors = true
+	rootCmd.SilenceUsage = true
+
+	rootCmd.SetArgs([]string{"--config", filename})
+	err = rootCmd.Execute()
+
+	var configErr *cmd.ConfigError
+	if assert.ErrorAs(t, err, &configErr) {
+		assert.EqualError(t, err, "Unmarshal config file: Resolve token: Exec references are disabled: exec://echo token")
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Copyright © 2021 Dirk Lembke <dirk@lembke.nz>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zauberhaus/42/background"
+	"github.com/zauberhaus/42/logger"
+)
+
+// DefaultStopTimeout is the time each service has to stop
+const DefaultStopTimeout = 10 * time.Second
+
+// Service is an initialized background process and the function it runs
+type Service struct {
+	Process *background.Process
+	Run     func(ctx context.Context) (bool, error)
+}
+
+// WithServices runs the services as the command, they are started in order
+// and stopped in reverse order after one failed or the context was cancelled
+func (r *RootCommand) WithServices(services ...Service) {
+	r.services = append(r.services, services...)
+	r.Run = nil
+	r.RunE = func(cmd *cobra.Command, args []string) error {
+		return r.runServices(cmd.Context())
+	}
+}
+
+// SetStopTimeout sets the time each service has to stop
+func (r *RootCommand) SetStopTimeout(d time.Duration) {
+	r.stopTimeout = d
+}
+
+func (r *RootCommand) runServices(ctx context.Context) error {
+	g := newServiceGroup(len(r.services), r.stopTimeout)
+
+	for _, s := range r.services {
+		if err := g.start(s); err != nil {
+			g.stop(r.stopTimeout)
+			return err
+		}
+	}
+
+	var err error
+
+	for g.running() > 0 && err == nil {
+		select {
+		case <-ctx.Done():
+			logger.Info("Stop services")
+			return g.stop(r.stopTimeout)
+		case res := <-g.results:
+			g.finish(res)
+			err = res.err
+		}
+	}
+
+	if stopErr := g.stop(r.stopTimeout); err == nil {
+		err = stopErr
+	}
+
+	return err
+}
+
+type serviceResult struct {
+	index int
+	err   error
+}
+
+// serviceGroup runs services and collects their results, it's the only
+// reader of the done channels of the processes
+type serviceGroup struct {
+	services []Service
+	results  chan serviceResult
+	finished []bool
+	errs     []error
+	timeout  time.Duration
+}
+
+func newServiceGroup(size int, timeout time.Duration) *serviceGroup {
+	return &serviceGroup{
+		results: make(chan serviceResult, size),
+		timeout: timeout,
+	}
+}
+
+// start runs the service and waits until it's initialized
+func (g *serviceGroup) start(s Service) error {
+	if err := <-s.Process.Run(s.Run); err != nil {
+		return err
+	}
+
+	index := len(g.services)
+
+	g.services = append(g.services, s)
+	g.finished = append(g.finished, false)
+	g.errs = append(g.errs, nil)
+
+	go func(done chan error) {
+		err := <-done
+		if err != nil {
+			g.drain(done)
+		}
+
+		g.results <- serviceResult{index: index, err: err}
+	}(s.Process.Done())
+
+	return nil
+}
+
+// drain waits until a failed process has run its close function and closed
+// the done channel or the stop timeout elapsed
+func (g *serviceGroup) drain(done chan error) {
+	timer := time.NewTimer(g.timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case _, ok := <-done:
+			if !ok {
+				return
+			}
+		case <-timer.C:
+			return
+		}
+	}
+}
+
+func (g *serviceGroup) finish(res serviceResult) {
+	g.finished[res.index] = true
+	g.errs[res.index] = res.err
+}
+
+func (g *serviceGroup) running() int {
+	count := 0
+	for _, f := range g.finished {
+		if !f {
+			count++
+		}
+	}
+
+	return count
+}
+
+// stop stops the running services in reverse order with a timeout for each
+// and returns the first error of them
+func (g *serviceGroup) stop(timeout time.Duration) error {
+	running := make([]bool, len(g.finished))
+	for i, f := range g.finished {
+		running[i] = !f
+	}
+
+	var result error
+
+	for i := len(g.services) - 1; i >= 0; i-- {
+		if !running[i] {
+			continue
+		}
+
+		if !g.finished[i] {
+			g.services[i].Process.Cancel()
+			g.wait(i, timeout)
+		}
+
+		if result == nil {
+			result = g.errs[i]
+		}
+	}
+
+	return result
+}
+
+// wait waits for the result of a service, results of other services are
+// recorded while waiting
+func (g *serviceGroup) wait(index int, timeout time.Duration) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for !g.finished[index] {
+		select {
+		case res := <-g.results:
+			g.finish(res)
+		case <-timer.C:
+			g.finished[index] = true
+			g.errs[index] = context.DeadlineExceeded
+		}
+	}
+}
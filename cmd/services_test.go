@@ -0,0 +1,161 @@
+package cmd_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zauberhaus/42/background"
+	"github.com/zauberhaus/42/cmd"
+	"github.com/zauberhaus/42/logger"
+)
+
+type serviceLog struct {
+	mutex  sync.Mutex
+	events []string
+}
+
+func (l *serviceLog) add(event string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.events = append(l.events, event)
+}
+
+func (l *serviceLog) service(name string, run func(ctx context.Context) (bool, error)) cmd.Service {
+	return l.slowService(name, 0, run)
+}
+
+func (l *serviceLog) slowService(name string, delay time.Duration, run func(ctx context.Context) (bool, error)) cmd.Service {
+	p := &background.Process{}
+	p.Init(name, func(ctx context.Context) error {
+		l.add("start " + name)
+		return nil
+	}, func(ctx context.Context) error {
+		time.Sleep(delay)
+		l.add("stop " + name)
+		return nil
+	}, logger.NewZapLogger())
+
+	return cmd.Service{Process: p, Run: run}
+}
+
+func wait(ctx context.Context) (bool, error) {
+	<-ctx.Done()
+	return false, nil
+}
+
+func TestServicesCancel(t *testing.T) {
+	log := &serviceLog{}
+
+	cfg := ShowConfig{}
+	rootCmd := cmd.NewRootCmd(&cobra.Command{Use: "test"}, &cfg)
+	rootCmd.WithServices(log.service("first", wait), log.service("second", wait))
+	rootCmd.SetArgs([]string{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.NoError(t, rootCmd.ExecuteContext(ctx))
+	assert.Equal(t, []string{"start first", "start second", "stop second", "stop first"}, log.events)
+}
+
+func TestServicesFailed(t *testing.T) {
+	log := &serviceLog{}
+	failed := errors.New("failed")
+
+	cfg := ShowConfig{}
+	rootCmd := cmd.NewRootCmd(&cobra.Command{Use: "test", SilenceErrors: true, SilenceUsage: true}, &cfg)
+	rootCmd.WithServices(
+		log.service("first", wait),
+		log.service("second", func(ctx context.Context) (bool, error) {
+			time.Sleep(10 * time.Millisecond)
+			return true, failed
+		}),
+		log.service("third", wait),
+	)
+
+	rootCmd.SetStopTimeout(time.Second)
+	rootCmd.SetArgs([]string{})
+
+	err := rootCmd.Execute()
+	assert.ErrorIs(t, err, failed)
+	assert.Equal(t, cmd.ExitRuntime, cmd.ExitCode(err))
+	assert.Equal(t, []string{"start first", "start second", "start third", "stop second", "stop third", "stop first"}, log.events)
+}
+
+func TestServicesFailedSlowClose(t *testing.T) {
+	log := &serviceLog{}
+	failed := errors.New("failed")
+
+	cfg := ShowConfig{}
+	rootCmd := cmd.NewRootCmd(&cobra.Command{Use: "test", SilenceErrors: true, SilenceUsage: true}, &cfg)
+	rootCmd.WithServices(
+		log.service("first", wait),
+		log.slowService("second", 200*time.Millisecond, func(ctx context.Context) (bool, error) {
+			return true, failed
+		}),
+	)
+
+	rootCmd.SetStopTimeout(time.Second)
+	rootCmd.SetArgs([]string{})
+
+	err := rootCmd.Execute()
+	assert.ErrorIs(t, err, failed)
+
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+
+	assert.Equal(t, []string{"start first", "start second", "stop second", "stop first"}, log.events)
+}
+
+func TestServicesStopFailed(t *testing.T) {
+	log := &serviceLog{}
+	failed := errors.New("stop failed")
+
+	cfg := ShowConfig{}
+	rootCmd := cmd.NewRootCmd(&cobra.Command{Use: "test", SilenceErrors: true, SilenceUsage: true}, &cfg)
+	rootCmd.WithServices(
+		log.service("first", wait),
+		log.service("second", func(ctx context.Context) (bool, error) {
+			<-ctx.Done()
+			return false, failed
+		}),
+	)
+
+	rootCmd.SetStopTimeout(time.Second)
+	rootCmd.SetArgs([]string{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := rootCmd.ExecuteContext(ctx)
+	assert.ErrorIs(t, err, failed)
+	assert.Equal(t, []string{"start first", "start second", "stop second", "stop first"}, log.events)
+}
+
+func TestServicesStopTimeout(t *testing.T) {
+	log := &serviceLog{}
+	release := make(chan struct{})
+	defer close(release)
+
+	cfg := ShowConfig{}
+	rootCmd := cmd.NewRootCmd(&cobra.Command{Use: "test", SilenceErrors: true, SilenceUsage: true}, &cfg)
+	rootCmd.WithServices(log.service("first", func(ctx context.Context) (bool, error) {
+		<-release
+		return false, nil
+	}))
+
+	rootCmd.SetStopTimeout(20 * time.Millisecond)
+	rootCmd.SetArgs([]string{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := rootCmd.ExecuteContext(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
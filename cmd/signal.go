@@ -0,0 +1,94 @@
+/*
+Copyright © 2021 Dirk Lembke <dirk@lembke.nz>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/zauberhaus/42/logger"
+)
+
+// DefaultGracePeriod is the time a command has to finish after the first signal
+const DefaultGracePeriod = 30 * time.Second
+
+// SetGracePeriod sets the time a command has to finish after the context was
+// cancelled by a signal before the program exits, 0 waits for a second signal
+func (r *RootCommand) SetGracePeriod(d time.Duration) {
+	r.gracePeriod = d
+}
+
+// signalContext returns a context, which is cancelled by SIGINT or SIGTERM,
+// a second signal or the end of the grace period exits the program
+func (r *RootCommand) signalContext(parent context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	done := make(chan struct{})
+	gracePeriod := r.gracePeriod
+
+	go func() {
+		var sig os.Signal
+
+		select {
+		case sig = <-signals:
+			logger.Warnf("Received signal %v, shutting down", sig)
+			cancel()
+		case <-done:
+			return
+		}
+
+		var timeout <-chan time.Time
+		if gracePeriod > 0 {
+			timer := time.NewTimer(gracePeriod)
+			defer timer.Stop()
+
+			timeout = timer.C
+		}
+
+		select {
+		case s := <-signals:
+			logger.Errorf("Received signal %v, exit", s)
+		case <-timeout:
+			logger.Errorf("Shutdown not finished after %v, exit", gracePeriod)
+		case <-done:
+			return
+		}
+
+		os.Exit(signalExitCode(sig))
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		close(done)
+		cancel()
+	}
+}
+
+// signalExitCode returns 128 + the signal number like shells do
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+
+	return ExitRuntime
+}
@@ -0,0 +1,59 @@
+package cmd_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zauberhaus/42/cmd"
+)
+
+func TestSignalContext(t *testing.T) {
+	cfg := ShowConfig{}
+	rootCmd := cmd.NewRootCmd(&cobra.Command{
+		Use: "test",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := os.FindProcess(os.Getpid())
+			if err != nil {
+				return err
+			}
+
+			if err := p.Signal(os.Interrupt); err != nil {
+				return err
+			}
+
+			select {
+			case <-cmd.Context().Done():
+				return cmd.Context().Err()
+			case <-time.After(5 * time.Second):
+				return nil
+			}
+		},
+	}, &cfg)
+
+	rootCmd.SetGracePeriod(time.Minute)
+	rootCmd.SetArgs([]string{})
+
+	err := rootCmd.Execute()
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, cmd.ExitRuntime, cmd.ExitCode(err))
+}
+
+func TestParentContext(t *testing.T) {
+	type key struct{}
+
+	cfg := ShowConfig{}
+	rootCmd := cmd.NewRootCmd(&cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			assert.Equal(t, "value", cmd.Context().Value(key{}))
+			assert.NoError(t, cmd.Context().Err())
+		},
+	}, &cfg)
+
+	rootCmd.SetArgs([]string{})
+	assert.NoError(t, rootCmd.ExecuteContext(context.WithValue(context.Background(), key{}, "value")))
+}
@@ -0,0 +1,56 @@
+/*
+Copyright © 2021 Dirk Lembke <dirk@lembke.nz>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// ConfigStore gives typed access to the config of a RootCommand
+type ConfigStore[T any] struct {
+	root *RootCommand
+}
+
+// NewTypedRootCmd creates a RootCommand for a new config of type T and its store
+func NewTypedRootCmd[T any](cmd *cobra.Command, opts ...Option) (*RootCommand, *ConfigStore[T]) {
+	r := NewRootCmd(cmd, new(T), opts...)
+	return r, &ConfigStore[T]{root: r}
+}
+
+// NewConfigStore returns a store for the config of r, which must be a *T
+func NewConfigStore[T any](r *RootCommand) (*ConfigStore[T], error) {
+	if _, ok := r.GetConfig().(*T); !ok {
+		return nil, fmt.Errorf("Config is not a %T: %T", new(T), r.GetConfig())
+	}
+
+	return &ConfigStore[T]{root: r}, nil
+}
+
+// Load returns a snapshot of the config, which isn't changed by reloads,
+// so it stays consistent while the config is reloaded
+func (s *ConfigStore[T]) Load() *T {
+	return s.root.GetConfig().(*T)
+}
+
+// OnChange registers a callback for config changes like OnConfigChange
+func (s *ConfigStore[T]) OnChange(f func(old *T, new *T)) {
+	s.root.OnConfigChange(func(old interface{}, new interface{}) {
+		f(old.(*T), new.(*T))
+	})
+}
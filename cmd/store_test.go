@@ -0,0 +1,94 @@
+package cmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zauberhaus/42/cmd"
+)
+
+func TestConfigStore(t *testing.T) {
+	t.Parallel()
+
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	if !assert.NoError(t, os.WriteFile(filename, []byte("name: old\nvalue: 1\n"), 0600)) {
+		return
+	}
+
+	changed := make(chan [2]*Config, 1)
+
+	var store *cmd.ConfigStore[Config]
+	var rootCmd *cmd.RootCommand
+
+	rootCmd, store = cmd.NewTypedRootCmd[Config](&cobra.Command{
+		Use: t.Name(),
+		Run: func(c *cobra.Command, args []string) {
+			snapshot := store.Load()
+			assert.Equal(t, "old", snapshot.Name)
+
+			stop := make(chan struct{})
+			wg := sync.WaitGroup{}
+
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for {
+						select {
+						case <-stop:
+							return
+						default:
+							cfg := store.Load()
+							assert.Equal(t, cfg.Name == "old", cfg.Value == 1)
+						}
+					}
+				}()
+			}
+
+			assert.NoError(t, os.WriteFile(filename, []byte("name: new\nvalue: 2\n"), 0600))
+
+			select {
+			case c := <-changed:
+				assert.Equal(t, snapshot, c[0])
+				assert.Equal(t, "new", c[1].Name)
+				assert.Equal(t, c[1], store.Load())
+			case <-time.After(5 * time.Second):
+				t.Error("Config change not received")
+			}
+
+			close(stop)
+			wg.Wait()
+
+			assert.Equal(t, "old", snapshot.Name)
+			assert.Equal(t, 1, snapshot.Value)
+		},
+	})
+
+	store.OnChange(func(old *Config, new *Config) {
+		select {
+		case changed <- [2]*Config{old, new}:
+		default:
+		}
+	})
+
+	rootCmd.SetArgs([]string{"--config", filename})
+	assert.NoError(t, rootCmd.Execute())
+}
+
+func TestNewConfigStore(t *testing.T) {
+	cfg := Config{}
+	rootCmd := cmd.NewRootCmd(&cobra.Command{Use: "test"}, &cfg)
+
+	store, err := cmd.NewConfigStore[Config](rootCmd)
+	if assert.NoError(t, err) {
+		assert.Same(t, &cfg, store.Load())
+	}
+
+	_, err = cmd.NewConfigStore[ShowConfig](rootCmd)
+	assert.EqualError(t, err, "Config is not a *cmd_test.ShowConfig: *cmd_test.Config")
+}
@@ -0,0 +1,386 @@
+/*
+Copyright © 2021 Dirk Lembke <dirk@lembke.nz>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+	"github.com/zauberhaus/42/generator"
+	"gopkg.in/yaml.v3"
+)
+
+// UnknownKeyError reports a key of a config file, which isn't in the config struct
+type UnknownKeyError struct {
+	Key        string
+	File       string
+	Line       int
+	Suggestion string
+}
+
+func (e *UnknownKeyError) Error() string {
+	msg := "unknown key " + e.Key
+	if e.Suggestion != "" {
+		msg += ", did you mean " + e.Suggestion + "?"
+	}
+
+	if e.Line > 0 {
+		return fmt.Sprintf("%v:%d: %v", e.File, e.Line, msg)
+	}
+
+	return fmt.Sprintf("%v: %v", e.File, msg)
+}
+
+// UnknownKeysError lists all unknown keys of the config files
+type UnknownKeysError struct {
+	Errors []*UnknownKeyError
+}
+
+func (e *UnknownKeysError) Error() string {
+	lines := []string{"Unknown config keys:"}
+	for _, err := range e.Errors {
+		lines = append(lines, "  - "+err.Error())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// SetStrict rejects config files with keys, which aren't in the config struct
+func (r *RootCommand) SetStrict(strict bool) {
+	r.strict = strict
+}
+
+// checkKeys returns an UnknownKeysError for keys of the config files, which
+// aren't in the config struct, if strict mode is enabled
+func (r *RootCommand) checkKeys() error {
+	if !r.strict {
+		return nil
+	}
+
+	r.mutex.RLock()
+	layers := append([]*configLayer{}, r.layers...)
+	r.mutex.RUnlock()
+
+	result := &UnknownKeysError{}
+
+	for _, l := range layers {
+		keys := layerKeys(reflect.TypeOf(r.GetConfig()), l.settings)
+		if len(keys) == 0 {
+			continue
+		}
+
+		lines := map[string]int{}
+		if data, err := os.ReadFile(l.file); err == nil {
+			lines = keyLines(l.file, data)
+		}
+
+		unknown := make([]*UnknownKeyError, 0, len(keys))
+		for _, k := range keys {
+			unknown = append(unknown, &UnknownKeyError{
+				Key:        k.key,
+				File:       l.file,
+				Line:       lines[k.key],
+				Suggestion: suggestKey(k.key, k.known),
+			})
+		}
+
+		sort.Slice(unknown, func(i, j int) bool {
+			if unknown[i].Line != unknown[j].Line {
+				return unknown[i].Line < unknown[j].Line
+			}
+
+			return unknown[i].Key < unknown[j].Key
+		})
+
+		result.Errors = append(result.Errors, unknown...)
+	}
+
+	if len(result.Errors) > 0 {
+		return result
+	}
+
+	return nil
+}
+
+// layerKeys returns the unknown keys of the settings of a config file, the
+// sections of all profiles are checked like profiles.<name>.<key>
+func layerKeys(t reflect.Type, settings map[string]interface{}) []unknownKey {
+	base := make(map[string]interface{}, len(settings))
+	for k, v := range settings {
+		base[k] = v
+	}
+
+	profiles, _ := base[profilesKey].(map[string]interface{})
+	delete(base, profilesKey)
+
+	result := unknownKeys(t, base, "")
+	for name, section := range profiles {
+		result = append(result, unknownKeys(t, section, joinKey(profilesKey, strings.ToLower(name)))...)
+	}
+
+	return result
+}
+
+type unknownKey struct {
+	key   string
+	known []string
+}
+
+// unknownKeys returns the keys of data, which aren't in the config type t,
+// with the known keys of the same struct, sub keys of unknown keys are skipped
+func unknownKeys(t reflect.Type, data interface{}, path string) []unknownKey {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if m, ok := data.(map[interface{}]interface{}); ok {
+		d := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			d[fmt.Sprint(k)] = v
+		}
+
+		data = d
+	}
+
+	result := []unknownKey{}
+
+	switch d := data.(type) {
+	case map[string]interface{}:
+		switch {
+		case t.Kind() == reflect.Struct && !isScalar(t):
+			for k, v := range d {
+				key := joinKey(path, strings.ToLower(k))
+
+				f, ok := fieldByKey(t, k)
+				if !ok {
+					result = append(result, unknownKey{key: key, known: fieldKeyNames(t, path)})
+					continue
+				}
+
+				result = append(result, unknownKeys(f.Type, v, key)...)
+			}
+		case t.Kind() == reflect.Map:
+			for k, v := range d {
+				result = append(result, unknownKeys(t.Elem(), v, joinKey(path, strings.ToLower(k)))...)
+			}
+		}
+	case []interface{}:
+		// hcl decodes a block into a list of objects
+		if t.Kind() == reflect.Struct && !isScalar(t) && len(d) == 1 {
+			return unknownKeys(t, d[0], path)
+		}
+
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i, v := range d {
+				result = append(result, unknownKeys(t.Elem(), v, fmt.Sprintf("%v[%d]", path, i))...)
+			}
+		}
+	}
+
+	return result
+}
+
+// fieldKeyNames returns the lower case keys of the fields of t below path
+func fieldKeyNames(t reflect.Type, path string) []string {
+	keys := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() || generator.Excluded(f) {
+			continue
+		}
+
+		keys = append(keys, joinKey(path, strings.ToLower(generator.KeyName(f))))
+	}
+
+	return keys
+}
+
+func joinKey(path string, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+// keyLines returns the lines of the keys of a yaml, json or toml file by
+// their lower case paths like database.host or servers[0].host
+func keyLines(file string, data []byte) map[string]int {
+	lines := map[string]int{}
+
+	switch strings.ToLower(strings.TrimPrefix(filepath.Ext(file), ".")) {
+	case "yaml", "yml":
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err == nil {
+			yamlLines(&node, "", lines)
+		}
+	case "json":
+		jsonLines(json.NewDecoder(bytes.NewReader(data)), data, "", lines)
+	case "toml":
+		if tree, err := toml.LoadBytes(data); err == nil {
+			tomlLines(tree, "", lines)
+		}
+	}
+
+	return lines
+}
+
+func yamlLines(n *yaml.Node, path string, lines map[string]int) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			yamlLines(c, path, lines)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := joinKey(path, strings.ToLower(n.Content[i].Value))
+			if _, ok := lines[key]; !ok {
+				lines[key] = n.Content[i].Line
+			}
+
+			yamlLines(n.Content[i+1], key, lines)
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			yamlLines(c, fmt.Sprintf("%v[%d]", path, i), lines)
+		}
+	}
+}
+
+func jsonLines(dec *json.Decoder, data []byte, path string, lines map[string]int) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+
+			key := joinKey(path, strings.ToLower(fmt.Sprint(tok)))
+			if _, ok := lines[key]; !ok {
+				lines[key] = bytes.Count(data[:dec.InputOffset()], []byte("\n")) + 1
+			}
+
+			if err := jsonLines(dec, data, key, lines); err != nil {
+				return err
+			}
+		}
+	case json.Delim('['):
+		for i := 0; dec.More(); i++ {
+			if err := jsonLines(dec, data, fmt.Sprintf("%v[%d]", path, i), lines); err != nil {
+				return err
+			}
+		}
+	default:
+		return nil
+	}
+
+	_, err = dec.Token()
+
+	return err
+}
+
+func tomlLines(tree *toml.Tree, path string, lines map[string]int) {
+	for _, k := range tree.Keys() {
+		key := joinKey(path, strings.ToLower(k))
+		lines[key] = tree.GetPositionPath([]string{k}).Line
+
+		switch v := tree.GetPath([]string{k}).(type) {
+		case *toml.Tree:
+			tomlLines(v, key, lines)
+		case []*toml.Tree:
+			for i, t := range v {
+				item := fmt.Sprintf("%v[%d]", key, i)
+				lines[item] = t.Position().Line
+				tomlLines(t, item, lines)
+			}
+		case []interface{}:
+			for i, item := range v {
+				if t, ok := item.(*toml.Tree); ok {
+					tomlLines(t, fmt.Sprintf("%v[%d]", key, i), lines)
+				}
+			}
+		}
+	}
+}
+
+// suggestKey returns the known key with the smallest edit distance to key,
+// if the distance is small enough
+func suggestKey(key string, known []string) string {
+	best := ""
+	limit := len(key)/3 + 1
+
+	for _, k := range known {
+		if d := distance(key, k); d < limit {
+			limit = d
+			best = k
+		}
+	}
+
+	return best
+}
+
+// distance returns the Levenshtein distance of a and b
+func distance(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+func minInt(values ...int) int {
+	result := values[0]
+	for _, v := range values[1:] {
+		if v < result {
+			result = v
+		}
+	}
+
+	return result
+}
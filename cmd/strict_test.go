@@ -0,0 +1,217 @@
+package cmd_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zauberhaus/42/cmd"
+)
+
+type StrictConfig struct {
+	Name     string
+	Database StrictDatabase `yaml:"database"`
+	Labels   map[string]string
+	Servers  []StrictServer
+}
+
+type StrictServer struct {
+	Host string
+}
+
+type StrictDatabase struct {
+	Host string
+	Port int
+}
+
+const strictFile = `name: test
+# databse settings
+labels:
+  team: a
+databse:
+  host: localhost
+database:
+  port: 5432
+  hots: db
+servers:
+  - host: a
+  - hots: b
+timeout: 10
+`
+
+func TestStrict(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if !assert.NoError(t, os.WriteFile(file, []byte(strictFile), 0600)) {
+		return
+	}
+
+	tests := map[string]struct {
+		args   []string
+		strict bool
+	}{
+		"flag":    {args: []string{"--config", file, "--strict"}},
+		"setting": {args: []string{"--config", file}, strict: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := StrictConfig{}
+			rootCmd := cmd.NewRootCmd(&cobra.Command{
+				Use:           "test",
+				SilenceErrors: true,
+				SilenceUsage:  true,
+				Run:           func(cmd *cobra.Command, args []string) {},
+			}, &cfg)
+
+			rootCmd.SetStrict(tt.strict)
+			rootCmd.SetArgs(tt.args)
+
+			err := rootCmd.Execute()
+			assert.Equal(t, cmd.ExitConfig, cmd.ExitCode(err))
+
+			var keysErr *cmd.UnknownKeysError
+			if assert.True(t, errors.As(err, &keysErr)) {
+				assert.Equal(t, []*cmd.UnknownKeyError{
+					{Key: "databse", File: file, Line: 5, Suggestion: "database"},
+					{Key: "database.hots", File: file, Line: 9, Suggestion: "database.host"},
+					{Key: "servers[1].hots", File: file, Line: 12, Suggestion: "servers[1].host"},
+					{Key: "timeout", File: file, Line: 13},
+				}, keysErr.Errors)
+
+				assert.Equal(t, "Unknown config keys:\n"+
+					"  - "+file+":5: unknown key databse, did you mean database?\n"+
+					"  - "+file+":9: unknown key database.hots, did you mean database.host?\n"+
+					"  - "+file+":12: unknown key servers[1].hots, did you mean servers[1].host?\n"+
+					"  - "+file+":13: unknown key timeout", err.Error())
+			}
+		})
+	}
+}
+
+const strictProfileFile = `name: test
+profiles:
+  dev:
+    databse:
+      host: localhost
+  prod:
+    name: prod
+    database:
+      hots: db
+`
+
+func TestStrictProfile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if !assert.NoError(t, os.WriteFile(file, []byte(strictProfileFile), 0600)) {
+		return
+	}
+
+	tests := map[string][]string{
+		"none":   {"--config", file, "--strict"},
+		"active": {"--config", file, "--strict", "--profile", "prod"},
+	}
+
+	for name, args := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := StrictConfig{}
+			rootCmd := cmd.NewRootCmd(&cobra.Command{
+				Use:           "test",
+				SilenceErrors: true,
+				SilenceUsage:  true,
+				Run:           func(cmd *cobra.Command, args []string) {},
+			}, &cfg)
+
+			rootCmd.SetArgs(args)
+
+			err := rootCmd.Execute()
+
+			var keysErr *cmd.UnknownKeysError
+			if assert.True(t, errors.As(err, &keysErr)) {
+				assert.Equal(t, []*cmd.UnknownKeyError{
+					{Key: "profiles.dev.databse", File: file, Line: 4, Suggestion: "profiles.dev.database"},
+					{Key: "profiles.prod.database.hots", File: file, Line: 9, Suggestion: "profiles.prod.database.host"},
+				}, keysErr.Errors)
+			}
+		})
+	}
+}
+
+func TestStrictFormats(t *testing.T) {
+	tests := map[string]string{
+		"json": `{
+  "name": "test",
+  "databse": {
+    "host": "localhost"
+  },
+  "servers": [
+    {"host": "a"},
+    {"hots": "b"}
+  ]
+}
+`,
+		"toml": `name = "test"
+# databse settings
+[databse]
+host = "localhost"
+
+[[servers]]
+host = "a"
+
+[[servers]]
+hots = "b"
+`,
+	}
+
+	wanted := map[string][]int{
+		"json": {3, 8},
+		"toml": {3, 10},
+	}
+
+	for format, content := range tests {
+		t.Run(format, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "config."+format)
+			if !assert.NoError(t, os.WriteFile(file, []byte(content), 0600)) {
+				return
+			}
+
+			cfg := StrictConfig{}
+			rootCmd := cmd.NewRootCmd(&cobra.Command{
+				Use:           "test",
+				SilenceErrors: true,
+				SilenceUsage:  true,
+				Run:           func(cmd *cobra.Command, args []string) {},
+			}, &cfg)
+
+			rootCmd.SetArgs([]string{"--config", file, "--strict"})
+
+			err := rootCmd.Execute()
+
+			var keysErr *cmd.UnknownKeysError
+			if assert.True(t, errors.As(err, &keysErr)) {
+				assert.Equal(t, []*cmd.UnknownKeyError{
+					{Key: "databse", File: file, Line: wanted[format][0], Suggestion: "database"},
+					{Key: "servers[1].hots", File: file, Line: wanted[format][1], Suggestion: "servers[1].host"},
+				}, keysErr.Errors)
+			}
+		})
+	}
+}
+
+func TestNotStrict(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if !assert.NoError(t, os.WriteFile(file, []byte(strictFile), 0600)) {
+		return
+	}
+
+	cfg := StrictConfig{}
+	rootCmd := cmd.NewRootCmd(&cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {},
+	}, &cfg)
+
+	rootCmd.SetArgs([]string{"--config", file})
+	assert.NoError(t, rootCmd.Execute())
+	assert.Equal(t, 5432, cfg.Database.Port)
+}
@@ -17,15 +17,22 @@ limitations under the License.
 package cmd
 
 import (
+	"encoding"
+	"fmt"
+	"net/url"
 	"reflect"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
-	"github.com/spf13/viper"
+	"github.com/zauberhaus/42/generator"
 	"github.com/zauberhaus/42/logger"
 )
 
-func BindCmdFlag(flags *pflag.FlagSet, names ...string) {
+// BindCmdFlag binds the flag source to the config key target, or to the
+// config key with the same name
+func (r *RootCommand) BindCmdFlag(flags *pflag.FlagSet, names ...string) {
 	if len(names) == 0 {
 		logger.Error("No source or target")
 		return
@@ -44,49 +51,307 @@ func BindCmdFlag(flags *pflag.FlagSet, names ...string) {
 		return
 	}
 
-	viper.BindPFlag(target, flag)
+	r.viper.BindPFlag(target, flag)
+	r.bindings.registerFlag(target, flag)
 }
 
-func AutoBindEnv(config interface{}) {
-	parseTags(viper.GetViper(), reflect.ValueOf(config).Type(), []string{}, []string{})
+// AutoBindEnv binds the config fields to env vars with the prefix and the
+// naming strategy of the command, it fails if different config keys are bound
+// to the same env var without the shared option like `env:"NAME,shared"`
+// or if an env var name is invalid
+func (r *RootCommand) AutoBindEnv(config interface{}) error {
+	namer := newEnvNamer(r.envPrefix, r.envNaming)
+
+	walkEnv(reflect.ValueOf(config).Type(), []string{}, []string{}, false, namer, func(f reflect.StructField, t reflect.Type, name string, env string, shared bool) {
+		if isStructSlice(t) {
+			r.bindings.registerIndexedEnv(f, name, env, namer.naming)
+			return
+		}
+
+		r.viper.BindEnv(name, env)
+		r.bindings.registerEnv(f, name, env, shared)
+	})
+
+	return checkBindings(r.bindings.list())
 }
 
-func parseTags(viper *viper.Viper, fieldType reflect.Type, path []string, envpath []string) {
+// walkEnv calls fn for every config value with its key and env var name
+func walkEnv(fieldType reflect.Type, path []string, envpath []string, shared bool, namer envNamer, fn func(f reflect.StructField, t reflect.Type, name string, env string, shared bool)) {
 	if fieldType.Kind() == reflect.Pointer {
 		fieldType = fieldType.Elem()
 	}
 
 	for i := 0; i < fieldType.NumField(); i++ {
 		f := fieldType.Field(i)
-		tag := f.Tag.Get("env")
+		if generator.Excluded(f) {
+			continue
+		}
+
+		tag, isShared := parseEnvTag(f.Tag.Get("env"))
+		isShared = isShared || shared
 		t := f.Type
 
 		if t.Kind() == reflect.Pointer {
 			t = t.Elem()
 		}
 
-		switch t.Kind() {
-		case reflect.Struct:
+		switch {
+		case t.Kind() == reflect.Struct && !isScalar(t):
 			subEnvPath := envpath
 			if tag != "" {
 				subEnvPath = append(envpath, strings.ToUpper(tag))
 			}
 
-			subPath := append(path, f.Name)
-			parseTags(viper, t, subPath, subEnvPath)
+			subPath := append(path, generator.KeyName(f))
+			walkEnv(t, subPath, subEnvPath, isShared, namer, fn)
 		default:
-			tmp := append(path, f.Name)
+			tmp := append(path, generator.KeyName(f))
 			name := strings.Join(tmp, ".")
 
 			if tag != "" && tag != "skip" {
 				tmp = append(envpath, strings.ToUpper(tag))
-				tag := strings.Join(tmp, "_")
-
-				viper.BindEnv(name, tag)
+				fn(f, t, name, namer.tagged(tmp), isShared)
 			} else {
-				envVar := strings.ToUpper(strings.ReplaceAll(strings.ReplaceAll(name, "-", "_"), ".", "_"))
-				viper.BindEnv(name, envVar)
+				fn(f, t, name, namer.name(tmp), isShared)
 			}
 		}
 	}
 }
+
+// parseEnvTag splits an env tag like "NAME,shared" into name and shared option
+func parseEnvTag(tag string) (string, bool) {
+	parts := strings.Split(tag, ",")
+	shared := false
+
+	for _, o := range parts[1:] {
+		if strings.TrimSpace(o) == "shared" {
+			shared = true
+		}
+	}
+
+	return strings.TrimSpace(parts[0]), shared
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	urlType             = reflect.TypeOf(url.URL{})
+)
+
+// isScalar returns true for structs, which are read from a single value
+func isScalar(t reflect.Type) bool {
+	return t == urlType || reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+func isStructSlice(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice {
+		return false
+	}
+
+	e := t.Elem()
+	if e.Kind() == reflect.Pointer {
+		e = e.Elem()
+	}
+
+	return e.Kind() == reflect.Struct && !isScalar(e)
+}
+
+func configKeys(fieldType reflect.Type, path []string) []string {
+	if fieldType.Kind() == reflect.Pointer {
+		fieldType = fieldType.Elem()
+	}
+
+	keys := []string{}
+
+	for i := 0; i < fieldType.NumField(); i++ {
+		f := fieldType.Field(i)
+		if generator.Excluded(f) {
+			continue
+		}
+
+		t := f.Type
+
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+
+		subPath := append(append([]string{}, path...), strings.ToLower(generator.KeyName(f)))
+
+		if t.Kind() == reflect.Struct && !isScalar(t) {
+			keys = append(keys, configKeys(t, subPath)...)
+		} else {
+			keys = append(keys, strings.Join(subPath, "."))
+		}
+	}
+
+	return keys
+}
+
+// AutoBindFlags creates a flag for every config field with a flag tag and
+// binds it to the matching config key
+func (r *RootCommand) AutoBindFlags(flags *pflag.FlagSet, config interface{}) error {
+	return r.parseFlags(flags, reflect.ValueOf(config).Type(), []string{})
+}
+
+func (r *RootCommand) parseFlags(flags *pflag.FlagSet, fieldType reflect.Type, path []string) error {
+	if fieldType.Kind() == reflect.Pointer {
+		fieldType = fieldType.Elem()
+	}
+
+	for i := 0; i < fieldType.NumField(); i++ {
+		f := fieldType.Field(i)
+		if generator.Excluded(f) {
+			continue
+		}
+
+		t := f.Type
+
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+
+		subPath := append(append([]string{}, path...), generator.KeyName(f))
+
+		if t.Kind() == reflect.Struct && !isScalar(t) {
+			if err := r.parseFlags(flags, t, subPath); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		name := f.Tag.Get("flag")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		if flags.Lookup(name) != nil {
+			return fmt.Errorf("Flag already defined: %v", name)
+		}
+
+		short := f.Tag.Get("short")
+		if len(short) > 1 {
+			return fmt.Errorf("Flag %v: Invalid shorthand: %v", name, short)
+		}
+
+		if short != "" && flags.ShorthandLookup(short) != nil {
+			return fmt.Errorf("Flag already defined: -%v", short)
+		}
+
+		err := addFlag(flags, t, name, short, f.Tag.Get("default"), f.Tag.Get("usage"))
+		if err != nil {
+			return fmt.Errorf("Flag %v: %v", name, err)
+		}
+
+		r.BindCmdFlag(flags, name, strings.Join(subPath, "."))
+	}
+
+	return nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func addFlag(flags *pflag.FlagSet, t reflect.Type, name string, short string, value string, usage string) error {
+	if t == durationType {
+		d := time.Duration(0)
+		if value != "" {
+			var err error
+			if d, err = time.ParseDuration(value); err != nil {
+				return err
+			}
+		}
+
+		flags.DurationP(name, short, d, usage)
+		return nil
+	}
+
+	if t.Kind() == reflect.Struct || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		flags.StringP(name, short, value, usage)
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		flags.StringP(name, short, value, usage)
+	case reflect.Bool:
+		b := false
+		if value != "" {
+			var err error
+			if b, err = strconv.ParseBool(value); err != nil {
+				return err
+			}
+		}
+
+		flags.BoolP(name, short, b, usage)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := int64(0)
+		if value != "" {
+			var err error
+			if i, err = strconv.ParseInt(value, 0, t.Bits()); err != nil {
+				return err
+			}
+		}
+
+		switch t.Kind() {
+		case reflect.Int:
+			flags.IntP(name, short, int(i), usage)
+		case reflect.Int8:
+			flags.Int8P(name, short, int8(i), usage)
+		case reflect.Int16:
+			flags.Int16P(name, short, int16(i), usage)
+		case reflect.Int32:
+			flags.Int32P(name, short, int32(i), usage)
+		default:
+			flags.Int64P(name, short, i, usage)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u := uint64(0)
+		if value != "" {
+			var err error
+			if u, err = strconv.ParseUint(value, 0, t.Bits()); err != nil {
+				return err
+			}
+		}
+
+		switch t.Kind() {
+		case reflect.Uint:
+			flags.UintP(name, short, uint(u), usage)
+		case reflect.Uint8:
+			flags.Uint8P(name, short, uint8(u), usage)
+		case reflect.Uint16:
+			flags.Uint16P(name, short, uint16(u), usage)
+		case reflect.Uint32:
+			flags.Uint32P(name, short, uint32(u), usage)
+		default:
+			flags.Uint64P(name, short, u, usage)
+		}
+	case reflect.Float32, reflect.Float64:
+		f := float64(0)
+		if value != "" {
+			var err error
+			if f, err = strconv.ParseFloat(value, t.Bits()); err != nil {
+				return err
+			}
+		}
+
+		if t.Kind() == reflect.Float32 {
+			flags.Float32P(name, short, float32(f), usage)
+		} else {
+			flags.Float64P(name, short, f, usage)
+		}
+	case reflect.Slice:
+		if t.Elem().Kind() != reflect.String {
+			return fmt.Errorf("Unsupported slice type: %v", t)
+		}
+
+		list := []string{}
+		if value != "" {
+			list = strings.Split(value, ",")
+		}
+
+		flags.StringSliceP(name, short, list, usage)
+	default:
+		return fmt.Errorf("Unsupported type: %v", t)
+	}
+
+	return nil
+}
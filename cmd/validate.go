@@ -0,0 +1,160 @@
+/*
+Copyright © 2021 Dirk Lembke <dirk@lembke.nz>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/zauberhaus/42/generator"
+)
+
+// Validator can be implemented by a config struct to add checks,
+// which can't be expressed by validate tags
+type Validator interface {
+	Validate() error
+}
+
+type FieldError struct {
+	Key     string
+	Env     []string
+	Flag    string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	sources := []string{}
+
+	for _, env := range e.Env {
+		sources = append(sources, "env "+env)
+	}
+
+	if e.Flag != "" {
+		sources = append(sources, "flag --"+e.Flag)
+	}
+
+	if len(sources) > 0 {
+		return fmt.Sprintf("%v (%v): %v", e.Key, strings.Join(sources, ", "), e.Message)
+	}
+
+	return fmt.Sprintf("%v: %v", e.Key, e.Message)
+}
+
+type ValidationError struct {
+	Errors []error
+}
+
+func (e *ValidationError) Error() string {
+	lines := []string{"Invalid config:"}
+	for _, err := range e.Errors {
+		lines = append(lines, "  - "+err.Error())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// validateConfig validates a config instance of the command
+func (r *RootCommand) validateConfig(config interface{}) error {
+	result := &ValidationError{}
+
+	validate := validator.New()
+	validate.RegisterTagNameFunc(generator.KeyName)
+
+	err := validate.Struct(config)
+	if err != nil {
+		var fieldErrors validator.ValidationErrors
+		if !errors.As(err, &fieldErrors) {
+			var invalid *validator.InvalidValidationError
+			if !errors.As(err, &invalid) {
+				return err
+			}
+		}
+
+		bindings := r.EnvBindings()
+
+		secrets := map[string]bool{}
+		for _, k := range generator.SecretKeys(config) {
+			secrets[k] = true
+		}
+
+		for _, fe := range fieldErrors {
+			key := configKey(fe.Namespace())
+			fieldError := &FieldError{
+				Key:     key,
+				Env:     unique(bindings[key]),
+				Message: validationMessage(fe, secrets[key]),
+			}
+
+			if flag := r.bindings.lookupFlag(key); flag != nil {
+				fieldError.Flag = flag.Name
+			}
+
+			result.Errors = append(result.Errors, fieldError)
+		}
+	}
+
+	if v, ok := config.(Validator); ok {
+		if err := v.Validate(); err != nil {
+			result.Errors = append(result.Errors, err)
+		}
+	}
+
+	if len(result.Errors) > 0 {
+		return result
+	}
+
+	return nil
+}
+
+func configKey(namespace string) string {
+	parts := strings.Split(namespace, ".")
+	if len(parts) > 1 {
+		parts = parts[1:]
+	}
+
+	return strings.ToLower(strings.Join(parts, "."))
+}
+
+func validationMessage(fe validator.FieldError, secret bool) string {
+	tag := fe.Tag()
+	if fe.Param() != "" {
+		tag += "=" + fe.Param()
+	}
+
+	value := fe.Value()
+	if secret {
+		value = generator.RedactedValue
+	}
+
+	return fmt.Sprintf("value '%v' failed on '%v'", value, tag)
+}
+
+func unique(list []string) []string {
+	result := []string{}
+	seen := map[string]bool{}
+
+	for _, v := range list {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,144 @@
+package cmd_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zauberhaus/42/cmd"
+)
+
+type ValidatedConfig struct {
+	Host    string          `env:"HOST" validate:"required"`
+	Port    int             `env:"PORT" default:"0" validate:"min=1"`
+	Mode    string          `default:"c" validate:"oneof=a b"`
+	Options ValidatedOption `env:"OPTIONS"`
+}
+
+type ValidatedOption struct {
+	Retries int `env:"RETRIES" default:"1" validate:"max=3"`
+}
+
+func (c *ValidatedConfig) Validate() error {
+	if c.Mode == "b" && c.Port == 80 {
+		return fmt.Errorf("mode b can't use port 80")
+	}
+
+	return nil
+}
+
+func TestValidationFailed(t *testing.T) {
+	cfg := ValidatedConfig{}
+	executed := false
+
+	rootCmd := cmd.NewRootCmd(
+		&cobra.Command{Use: t.Name(),
+			Short: "Test program",
+			Run: func(cmd *cobra.Command, args []string) {
+				executed = true
+			},
+		}, &cfg,
+	)
+
+	rootCmd.WithInit(func(rc *cmd.RootCommand) {
+		rc.Flags().IntP("port", "p", 0, "Port")
+		rc.BindCmdFlag(rc.Flags(), "port")
+	})
+
+	os.Setenv("OPTIONS_RETRIES", "5")
+	defer os.Unsetenv("OPTIONS_RETRIES")
+
+	rootCmd.SetArgs([]string{})
+	rootCmd.SilenceUsage = true
+
+	err := rootCmd.Execute()
+	if assert.Error(t, err) {
+		assert.False(t, executed)
+
+		verr, ok := err.(*cmd.ValidationError)
+		if assert.True(t, ok) && assert.Len(t, verr.Errors, 4) {
+			assert.Equal(t, &cmd.FieldError{Key: "host", Env: []string{"HOST"}, Message: "value '' failed on 'required'"}, verr.Errors[0])
+			assert.Equal(t, &cmd.FieldError{Key: "port", Env: []string{"PORT"}, Flag: "port", Message: "value '0' failed on 'min=1'"}, verr.Errors[1])
+			assert.Equal(t, &cmd.FieldError{Key: "mode", Env: []string{"MODE"}, Message: "value 'c' failed on 'oneof=a b'"}, verr.Errors[2])
+			assert.Equal(t, &cmd.FieldError{Key: "options.retries", Env: []string{"OPTIONS_RETRIES"}, Message: "value '5' failed on 'max=3'"}, verr.Errors[3])
+		}
+
+		assert.Contains(t, err.Error(), "port (env PORT, flag --port): value '0' failed on 'min=1'")
+	}
+}
+
+func TestValidationMethod(t *testing.T) {
+	cfg := ValidatedConfig{}
+
+	rootCmd := cmd.NewRootCmd(
+		&cobra.Command{Use: t.Name(),
+			Short: "Test program",
+			Run:   func(cmd *cobra.Command, args []string) {},
+		}, &cfg,
+	)
+
+	os.Setenv("HOST", "localhost")
+	os.Setenv("PORT", "80")
+	os.Setenv("MODE", "b")
+	defer os.Unsetenv("HOST")
+	defer os.Unsetenv("PORT")
+	defer os.Unsetenv("MODE")
+
+	rootCmd.SetArgs([]string{})
+	rootCmd.SilenceUsage = true
+
+	err := rootCmd.Execute()
+	assert.EqualError(t, err, "Invalid config:\n  - mode b can't use port 80")
+}
+
+type ReloadedConfig struct {
+	Name  string `validate:"required"`
+	Limit int    `validate:"max=100"`
+}
+
+func TestReloadValidation(t *testing.T) {
+	t.Parallel()
+
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	if !assert.NoError(t, os.WriteFile(filename, []byte("name: old\nlimit: 1\n"), 0600)) {
+		return
+	}
+
+	changed := make(chan [2]*ReloadedConfig, 1)
+
+	cfg := ReloadedConfig{}
+	rootCmd := cmd.NewRootCmd(&cobra.Command{
+		Use: t.Name(),
+		Run: func(c *cobra.Command, args []string) {
+			assert.NoError(t, os.WriteFile(filename, []byte("name: invalid\nlimit: 1000\n"), 0600))
+			time.Sleep(200 * time.Millisecond)
+			assert.NoError(t, os.WriteFile(filename, []byte("name: new\nlimit: 2\n"), 0600))
+
+			select {
+			case c := <-changed:
+				assert.Equal(t, &ReloadedConfig{Name: "old", Limit: 1}, c[0])
+				assert.Equal(t, &ReloadedConfig{Name: "new", Limit: 2}, c[1])
+			case <-time.After(5 * time.Second):
+				t.Error("Config change not received")
+			}
+		},
+	}, &cfg)
+
+	rootCmd.OnConfigChange(func(old interface{}, new interface{}) {
+		select {
+		case changed <- [2]*ReloadedConfig{old.(*ReloadedConfig), new.(*ReloadedConfig)}:
+		default:
+		}
+	})
+
+	rootCmd.OnKeyChange("limit", func(key string, old interface{}, new interface{}) {
+		assert.NotEqual(t, 1000, new)
+	})
+
+	rootCmd.SetArgs([]string{"--config", filename})
+	assert.NoError(t, rootCmd.Execute())
+}
@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"runtime"
+	"runtime/debug"
 
+	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
 
@@ -22,9 +26,40 @@ func (v *Version) String() string {
 	return string(data)
 }
 
-// NewVersion creates a new version object
+// Short returns the git version, or the commit if there is no tag
+func (v *Version) Short() string {
+	switch {
+	case v.GitVersion != "":
+		return v.GitVersion
+	case v.GitCommit != "":
+		return v.GitCommit
+	default:
+		return "devel"
+	}
+}
+
+// Marshal formats the version as yaml, json, xml or short
+func (v *Version) Marshal(format string) ([]byte, error) {
+	switch format {
+	case "yaml", "yml":
+		return yaml.Marshal(v)
+	case "json":
+		data, err := json.MarshalIndent(v, "", "  ")
+		return append(data, '\n'), err
+	case "xml":
+		data, err := xml.MarshalIndent(v, "", "  ")
+		return append(data, '\n'), err
+	case "short":
+		return []byte(v.Short() + "\n"), nil
+	default:
+		return nil, fmt.Errorf("Unknown output format: %v", format)
+	}
+}
+
+// NewVersion creates a new version object, empty values are taken
+// from the VCS info of the build if available
 func NewVersion(buildDate string, gitCommit string, tag string, treeState string) *Version {
-	return &Version{
+	v := &Version{
 		BuildDate:    buildDate,
 		Compiler:     runtime.Compiler,
 		GitCommit:    gitCommit,
@@ -33,4 +68,69 @@ func NewVersion(buildDate string, gitCommit string, tag string, treeState string
 		GoVersion:    runtime.Version(),
 		Platform:     fmt.Sprintf("%v/%v", runtime.GOOS, runtime.GOARCH),
 	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		v.SetBuildSettings(info.Settings)
+
+		if v.GitVersion == "" && info.Main.Version != "(devel)" {
+			v.GitVersion = info.Main.Version
+		}
+	}
+
+	return v
+}
+
+// SetBuildSettings sets the empty commit, build date and tree state from the
+// vcs.revision, vcs.time and vcs.modified settings of the build info
+func (v *Version) SetBuildSettings(settings []debug.BuildSetting) {
+	for _, s := range settings {
+		switch s.Key {
+		case "vcs.revision":
+			if v.GitCommit == "" {
+				v.GitCommit = s.Value
+			}
+		case "vcs.time":
+			if v.BuildDate == "" {
+				v.BuildDate = s.Value
+			}
+		case "vcs.modified":
+			if v.GitTreeState == "" {
+				if s.Value == "true" {
+					v.GitTreeState = "dirty"
+				} else {
+					v.GitTreeState = "clean"
+				}
+			}
+		}
+	}
+}
+
+// VersionCommand adds the version command and the --version flag
+func VersionCommand(r *RootCommand) {
+	var format string
+
+	versionCmd := &cobra.Command{
+		Use:   "version",
+		Short: "Show the version info",
+		Args:  cobra.NoArgs,
+		Annotations: map[string]string{
+			skipValidation: "true",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := r.GetVersion().Marshal(format)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprint(cmd.OutOrStdout(), string(data))
+			return nil
+		},
+	}
+
+	versionCmd.Flags().StringVarP(&format, "output", "o", "yaml", "Output format (yaml, json, xml, short)")
+
+	r.Version = r.GetVersion().Short()
+	r.SetVersionTemplate("{{.Version}}\n")
+
+	r.AddCommand(versionCmd)
 }
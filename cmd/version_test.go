@@ -0,0 +1,120 @@
+package cmd_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"runtime/debug"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zauberhaus/42/cmd"
+	"gopkg.in/yaml.v3"
+)
+
+func TestVersionCommand(t *testing.T) {
+	t.Parallel()
+
+	version := cmd.NewVersion("today", "123456", "v1.1.1", "dirty")
+
+	unmarshal := map[string]func([]byte, interface{}) error{
+		"yaml": yaml.Unmarshal,
+		"json": json.Unmarshal,
+		"xml":  xml.Unmarshal,
+	}
+
+	for format, f := range unmarshal {
+		t.Run(t.Name()+"_"+format, func(t *testing.T) {
+			output := runVersion(t, version, "version", "-o", format)
+
+			var info cmd.Version
+			err := f(output, &info)
+			if assert.NoError(t, err) {
+				assert.Equal(t, version, &info)
+			}
+		})
+	}
+}
+
+func TestVersionShort(t *testing.T) {
+	t.Parallel()
+
+	version := cmd.NewVersion("today", "123456", "v1.1.1", "dirty")
+
+	output := runVersion(t, version, "version", "-o", "short")
+	assert.Equal(t, "v1.1.1\n", string(output))
+
+	output = runVersion(t, version, "--version")
+	assert.Equal(t, "v1.1.1\n", string(output))
+
+	version = cmd.NewVersion("today", "123456", "", "dirty")
+	output = runVersion(t, version, "version", "-o", "short")
+	assert.Equal(t, "123456\n", string(output))
+}
+
+func TestVersionBuildInfo(t *testing.T) {
+	t.Parallel()
+
+	version := cmd.NewVersion("", "", "", "")
+	assert.NotEmpty(t, version.GoVersion)
+	assert.NotEmpty(t, version.Short())
+}
+
+func TestVersionBuildSettings(t *testing.T) {
+	t.Parallel()
+
+	settings := []debug.BuildSetting{
+		{Key: "-compiler", Value: "gc"},
+		{Key: "vcs", Value: "git"},
+		{Key: "vcs.revision", Value: "3a9b10c"},
+		{Key: "vcs.time", Value: "2022-03-01T10:00:00Z"},
+		{Key: "vcs.modified", Value: "true"},
+	}
+
+	version := &cmd.Version{}
+	version.SetBuildSettings(settings)
+
+	assert.Equal(t, "3a9b10c", version.GitCommit)
+	assert.Equal(t, "2022-03-01T10:00:00Z", version.BuildDate)
+	assert.Equal(t, "dirty", version.GitTreeState)
+	assert.Equal(t, "3a9b10c", version.Short())
+
+	version = &cmd.Version{}
+	version.SetBuildSettings([]debug.BuildSetting{{Key: "vcs.modified", Value: "false"}})
+	assert.Equal(t, "clean", version.GitTreeState)
+	assert.Empty(t, version.GitCommit)
+	assert.Equal(t, "devel", version.Short())
+
+	version = &cmd.Version{
+		BuildDate:    "today",
+		GitCommit:    "123456",
+		GitTreeState: "clean",
+	}
+
+	version.SetBuildSettings(settings)
+	assert.Equal(t, "123456", version.GitCommit)
+	assert.Equal(t, "today", version.BuildDate)
+	assert.Equal(t, "clean", version.GitTreeState)
+}
+
+func runVersion(t *testing.T, version *cmd.Version, args ...string) []byte {
+	rootCmd := cmd.NewRootCmd(
+		&cobra.Command{Use: t.Name(),
+			Short: "Test program",
+			Run:   func(cmd *cobra.Command, args []string) {},
+		}, &Config{},
+	)
+
+	rootCmd.SetVersion(version)
+	rootCmd.WithSubCommands(cmd.VersionCommand)
+	rootCmd.SetArgs(args)
+
+	output := &bytes.Buffer{}
+	rootCmd.SetOut(output)
+
+	err := rootCmd.Execute()
+	assert.NoError(t, err)
+
+	return output.Bytes()
+}
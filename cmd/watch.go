@@ -0,0 +1,154 @@
+/*
+Copyright © 2021 Dirk Lembke <dirk@lembke.nz>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/zauberhaus/42/logger"
+)
+
+type configWatcher struct {
+	watcher *fsnotify.Watcher
+	mutex   sync.Mutex
+	files   map[string]bool
+	dirs    map[string]bool
+	done    chan struct{}
+}
+
+// watchConfig watches the directories of all config files to pick up
+// renames and atomic saves, the watcher is started with the first call
+func (r *RootCommand) watchConfig(files []string) {
+	r.mutex.Lock()
+	if r.watcher == nil {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			r.mutex.Unlock()
+			logger.Errorf("Watch config files: %v", err)
+			return
+		}
+
+		r.watcher = &configWatcher{
+			watcher: watcher,
+			files:   map[string]bool{},
+			dirs:    map[string]bool{},
+			done:    make(chan struct{}),
+		}
+
+		go r.watch(r.watcher)
+	}
+
+	w := r.watcher
+	r.mutex.Unlock()
+
+	w.watchFiles(files)
+}
+
+// watchFiles replaces the watched config files
+func (w *configWatcher) watchFiles(files []string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.files = map[string]bool{}
+
+	for _, file := range files {
+		file = filepath.Clean(file)
+		if abs, err := filepath.Abs(file); err == nil {
+			file = abs
+		}
+
+		w.files[file] = true
+
+		dir := filepath.Dir(file)
+		if !w.dirs[dir] {
+			if err := w.watcher.Add(dir); err != nil {
+				logger.Errorf("Watch config dir %v: %v", dir, err)
+				continue
+			}
+
+			w.dirs[dir] = true
+		}
+	}
+}
+
+// unwatchConfig closes the watcher and waits until a running reload finished
+func (r *RootCommand) unwatchConfig() {
+	r.mutex.Lock()
+	w := r.watcher
+	r.watcher = nil
+	r.mutex.Unlock()
+
+	if w == nil {
+		return
+	}
+
+	if err := w.watcher.Close(); err != nil {
+		logger.Errorf("Close config watcher: %v", err)
+	}
+
+	<-w.done
+}
+
+func (w *configWatcher) isConfigFile(name string) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if abs, err := filepath.Abs(name); err == nil {
+		name = abs
+	}
+
+	return w.files[filepath.Clean(name)]
+}
+
+func (r *RootCommand) watch(w *configWatcher) {
+	defer close(w.done)
+
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+
+			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 || !w.isConfigFile(event.Name) {
+				continue
+			}
+
+			logger.Infof("Config file changed: %v", event.Name)
+
+			files, err := r.readConfig()
+			if err != nil {
+				logger.Errorf("Read config files: %v", err)
+				continue
+			}
+
+			w.watchFiles(files)
+
+			if err := r.reloadConfig(); err != nil {
+				logger.Errorf("Reload config file: %v", err)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+
+			logger.Errorf("Watch config files: %v", err)
+		}
+	}
+}
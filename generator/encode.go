@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"reflect"
 
 	"github.com/pelletier/go-toml"
 	"gopkg.in/yaml.v3"
@@ -16,6 +17,10 @@ import (
 func Marshal(cfg interface{}, file string) ([]byte, error) {
 
 	ext := filepath.Ext(file)[1:]
+	cfg = Redact(cfg)
+	if cfg != nil {
+		cfg = keyed(reflect.ValueOf(cfg)).Interface()
+	}
 
 	switch ext {
 	case "yml", "yaml":
@@ -0,0 +1,68 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MarshalEnv writes grouped env bindings as dotenv, export, k8s or compose.
+// The groups map config keys to env vars, as returned by GroupBindings.
+func MarshalEnv(groups []map[string]string, value func(key string) string, format string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch format {
+	case "dotenv", "export":
+	case "k8s":
+		buf.WriteString("env:\n")
+	case "compose":
+		buf.WriteString("environment:\n")
+	default:
+		return nil, fmt.Errorf("Unknown env format: %v", format)
+	}
+
+	for idx, group := range groups {
+		if idx > 0 {
+			buf.WriteString("\n")
+		}
+
+		vars := make(map[string]string, len(group))
+		names := make([]string, 0, len(group))
+		for k, v := range group {
+			vars[v] = k
+			names = append(names, v)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			val := value(vars[name])
+
+			switch format {
+			case "dotenv":
+				fmt.Fprintf(&buf, "%v=%v\n", name, quoteDotEnv(val))
+			case "export":
+				fmt.Fprintf(&buf, "export %v=%v\n", name, quoteShell(val))
+			case "k8s":
+				fmt.Fprintf(&buf, "  - name: %v\n    value: %v\n", name, strconv.Quote(val))
+			case "compose":
+				fmt.Fprintf(&buf, "  %v: %v\n", name, strconv.Quote(val))
+			}
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func quoteDotEnv(val string) string {
+	if val == "" || strings.ContainsAny(val, " \t\n\r\"'#$\\=`") {
+		return strconv.Quote(val)
+	}
+
+	return val
+}
+
+func quoteShell(val string) string {
+	return "'" + strings.ReplaceAll(val, "'", `'\''`) + "'"
+}
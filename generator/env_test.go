@@ -0,0 +1,62 @@
+package generator_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zauberhaus/42/generator"
+)
+
+func TestMarshalEnv(t *testing.T) {
+	groups, err := generator.GroupBindings(map[string][]string{
+		"name":         {"NAME"},
+		"value":        {"VALUE"},
+		"options.path": {"OPTIONS_PATH"},
+		"options.name": {"OPTIONS_NAME"},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	values := map[string]string{
+		"name":         "it's me",
+		"value":        "1",
+		"options.path": "/tmp",
+		"options.name": "test",
+	}
+
+	value := func(key string) string {
+		return values[key]
+	}
+
+	tests := map[string]string{
+		"dotenv": "NAME=\"it's me\"\n\nVALUE=1\n\nOPTIONS_NAME=test\nOPTIONS_PATH=/tmp\n",
+		"export": "export NAME='it'\\''s me'\n\nexport VALUE='1'\n\nexport OPTIONS_NAME='test'\nexport OPTIONS_PATH='/tmp'\n",
+		"k8s": "env:\n" +
+			"  - name: NAME\n    value: \"it's me\"\n" +
+			"\n" +
+			"  - name: VALUE\n    value: \"1\"\n" +
+			"\n" +
+			"  - name: OPTIONS_NAME\n    value: \"test\"\n" +
+			"  - name: OPTIONS_PATH\n    value: \"/tmp\"\n",
+		"compose": "environment:\n" +
+			"  NAME: \"it's me\"\n" +
+			"\n" +
+			"  VALUE: \"1\"\n" +
+			"\n" +
+			"  OPTIONS_NAME: \"test\"\n" +
+			"  OPTIONS_PATH: \"/tmp\"\n",
+	}
+
+	for format, wanted := range tests {
+		t.Run(t.Name()+"_"+format, func(t *testing.T) {
+			data, err := generator.MarshalEnv(groups, value, format)
+			if assert.NoError(t, err) {
+				assert.Equal(t, wanted, string(data))
+			}
+		})
+	}
+
+	_, err = generator.MarshalEnv(groups, value, "xml")
+	assert.EqualError(t, err, "Unknown env format: xml")
+}
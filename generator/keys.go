@@ -0,0 +1,156 @@
+package generator
+
+import (
+	"encoding"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// keyTags are the struct tags used for config key names in this order
+var keyTags = []string{"mapstructure", "yaml", "json"}
+
+// KeyName returns the config key name of a field from the first of the
+// mapstructure, yaml or json tags with a name, or the field name,
+// it's - for fields, which aren't part of the config
+func KeyName(f reflect.StructField) string {
+	name, _ := keyTag(f)
+	return name
+}
+
+// Excluded returns true for fields with a - as key name
+func Excluded(f reflect.StructField) bool {
+	return KeyName(f) == "-"
+}
+
+// keyTag returns the key name and the tag options of a field, the options
+// of tags without name like `yaml:",inline"` are kept for the field name
+func keyTag(f reflect.StructField) (string, string) {
+	options := ""
+
+	for _, t := range keyTags {
+		name, opts, _ := strings.Cut(f.Tag.Get(t), ",")
+		if name == "" {
+			if options == "" {
+				options = opts
+			}
+
+			continue
+		}
+
+		return name, opts
+	}
+
+	return f.Name, options
+}
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	keyedTypes        = map[reflect.Type]reflect.Type{}
+	keyedMutex        sync.Mutex
+)
+
+// keyed returns a copy of v, which all encoders write with the config key names
+func keyed(v reflect.Value) reflect.Value {
+	keyedMutex.Lock()
+	t := keyedType(v.Type())
+	keyedMutex.Unlock()
+
+	if t == v.Type() {
+		return v
+	}
+
+	result := reflect.New(t).Elem()
+	copyKeyed(v, result)
+
+	return result
+}
+
+func copyKeyed(src reflect.Value, dst reflect.Value) {
+	if src.Type() == dst.Type() {
+		dst.Set(src)
+		return
+	}
+
+	switch src.Kind() {
+	case reflect.Pointer:
+		if src.IsNil() {
+			return
+		}
+
+		dst.Set(reflect.New(dst.Type().Elem()))
+		copyKeyed(src.Elem(), dst.Elem())
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+
+		dst.Set(reflect.MakeSlice(dst.Type(), src.Len(), src.Len()))
+		for i := 0; i < src.Len(); i++ {
+			copyKeyed(src.Index(i), dst.Index(i))
+		}
+	case reflect.Struct:
+		j := 0
+		for i := 0; i < src.NumField(); i++ {
+			if f := src.Type().Field(i); !f.IsExported() || Excluded(f) {
+				continue
+			}
+
+			copyKeyed(src.Field(i), dst.Field(j))
+			j++
+		}
+	}
+}
+
+func keyedType(t reflect.Type) reflect.Type {
+	if k, ok := keyedTypes[t]; ok {
+		return k
+	}
+
+	k := t
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if e := keyedType(t.Elem()); e != t.Elem() {
+			k = reflect.PointerTo(e)
+		}
+	case reflect.Slice:
+		if e := keyedType(t.Elem()); e != t.Elem() {
+			k = reflect.SliceOf(e)
+		}
+	case reflect.Struct:
+		if t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+			break
+		}
+
+		fields := []reflect.StructField{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() || Excluded(f) {
+				continue
+			}
+
+			name, options := keyTag(f)
+			if name == f.Name {
+				name = strings.ToLower(name)
+			}
+
+			tag := name
+			if options != "" {
+				tag += "," + options
+			}
+
+			fields = append(fields, reflect.StructField{
+				Name: f.Name,
+				Type: keyedType(f.Type),
+				Tag:  reflect.StructTag(`yaml:"` + tag + `" json:"` + tag + `" toml:"` + name + `"`),
+			})
+		}
+
+		k = reflect.StructOf(fields)
+	}
+
+	keyedTypes[t] = k
+
+	return k
+}
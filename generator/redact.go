@@ -0,0 +1,136 @@
+package generator
+
+import (
+	"reflect"
+	"strings"
+)
+
+// RedactedValue replaces the values of fields tagged with `secret:"true"`
+const RedactedValue = "******"
+
+// Redact returns a copy of cfg with all non-empty secret fields redacted
+func Redact(cfg interface{}) interface{} {
+	if cfg == nil {
+		return nil
+	}
+
+	return redact(reflect.ValueOf(cfg)).Interface()
+}
+
+func redact(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+
+		c := redact(v.Elem())
+		p := reflect.New(c.Type())
+		p.Elem().Set(c)
+
+		return p
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+
+		for i := 0; i < v.NumField(); i++ {
+			f := c.Field(i)
+			if !f.CanSet() {
+				continue
+			}
+
+			if IsSecret(v.Type().Field(i)) {
+				if f.IsZero() {
+					continue
+				}
+
+				if f.Kind() == reflect.String {
+					f.SetString(RedactedValue)
+				} else {
+					f.Set(reflect.Zero(f.Type()))
+				}
+			} else {
+				f.Set(redact(v.Field(i)))
+			}
+		}
+
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+
+		return redact(v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(redact(v.Index(i)))
+		}
+
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(redact(v.Index(i)))
+		}
+
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), redact(iter.Value()))
+		}
+
+		return c
+	default:
+		return v
+	}
+}
+
+// SecretKeys returns the lower case config keys of all secret fields
+func SecretKeys(cfg interface{}) []string {
+	return secretKeys(reflect.TypeOf(cfg), []string{})
+}
+
+func secretKeys(t reflect.Type, path []string) []string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	keys := []string{}
+
+	if t.Kind() != reflect.Struct {
+		return keys
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if Excluded(f) {
+			continue
+		}
+
+		subPath := append(append([]string{}, path...), strings.ToLower(KeyName(f)))
+
+		if IsSecret(f) {
+			keys = append(keys, strings.Join(subPath, "."))
+		} else {
+			keys = append(keys, secretKeys(f.Type, subPath)...)
+		}
+	}
+
+	return keys
+}
+
+// IsSecret returns true for fields tagged with `secret:"true"`
+func IsSecret(f reflect.StructField) bool {
+	return f.Tag.Get("secret") == "true"
+}
@@ -0,0 +1,118 @@
+package generator_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zauberhaus/42/generator"
+)
+
+type SecretConfig struct {
+	User     string
+	Password string `secret:"true"`
+	Token    string `secret:"true"`
+	Pin      int    `secret:"true"`
+	Database *SecretDatabase
+}
+
+type SecretDatabase struct {
+	Host     string
+	Password string `secret:"true"`
+}
+
+func TestRedact(t *testing.T) {
+	cfg := &SecretConfig{
+		User:     "user",
+		Password: "password",
+		Pin:      1234,
+		Database: &SecretDatabase{
+			Host:     "localhost",
+			Password: "db",
+		},
+	}
+
+	redacted := generator.Redact(cfg).(*SecretConfig)
+
+	assert.Equal(t, &SecretConfig{
+		User:     "user",
+		Password: generator.RedactedValue,
+		Database: &SecretDatabase{
+			Host:     "localhost",
+			Password: generator.RedactedValue,
+		},
+	}, redacted)
+
+	assert.Equal(t, "password", cfg.Password)
+	assert.Equal(t, 1234, cfg.Pin)
+	assert.Equal(t, "db", cfg.Database.Password)
+}
+
+type SecretCollections struct {
+	Servers  []SecretDatabase
+	Backends [2]*SecretDatabase
+	Named    map[string]SecretDatabase
+	Any      map[string]interface{}
+}
+
+func TestRedactCollections(t *testing.T) {
+	cfg := &SecretCollections{
+		Servers: []SecretDatabase{
+			{Host: "a", Password: "pa"},
+			{Host: "b"},
+		},
+		Backends: [2]*SecretDatabase{
+			{Host: "c", Password: "pc"},
+		},
+		Named: map[string]SecretDatabase{
+			"d": {Host: "d", Password: "pd"},
+		},
+		Any: map[string]interface{}{
+			"e":    SecretDatabase{Host: "e", Password: "pe"},
+			"list": []interface{}{&SecretDatabase{Host: "f", Password: "pf"}},
+			"port": 8080,
+		},
+	}
+
+	redacted := generator.Redact(cfg).(*SecretCollections)
+
+	assert.Equal(t, &SecretCollections{
+		Servers: []SecretDatabase{
+			{Host: "a", Password: generator.RedactedValue},
+			{Host: "b"},
+		},
+		Backends: [2]*SecretDatabase{
+			{Host: "c", Password: generator.RedactedValue},
+		},
+		Named: map[string]SecretDatabase{
+			"d": {Host: "d", Password: generator.RedactedValue},
+		},
+		Any: map[string]interface{}{
+			"e":    SecretDatabase{Host: "e", Password: generator.RedactedValue},
+			"list": []interface{}{&SecretDatabase{Host: "f", Password: generator.RedactedValue}},
+			"port": 8080,
+		},
+	}, redacted)
+
+	assert.Equal(t, "pa", cfg.Servers[0].Password)
+	assert.Equal(t, "pc", cfg.Backends[0].Password)
+	assert.Equal(t, "pd", cfg.Named["d"].Password)
+	assert.Equal(t, "pe", cfg.Any["e"].(SecretDatabase).Password)
+	assert.Equal(t, "pf", cfg.Any["list"].([]interface{})[0].(*SecretDatabase).Password)
+}
+
+func TestMarshalRedacted(t *testing.T) {
+	cfg := SecretConfig{
+		User:     "user",
+		Password: "password",
+	}
+
+	data, err := generator.Marshal(cfg, "config.yaml")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "user: user\npassword: '******'\ntoken: \"\"\npin: 0\ndatabase: null\n", string(data))
+	}
+}
+
+func TestSecretKeys(t *testing.T) {
+	keys := generator.SecretKeys(&SecretConfig{})
+	assert.Equal(t, []string{"password", "token", "pin", "database.password"}, keys)
+}
@@ -38,6 +38,10 @@ func GroupBindings(env map[string][]string) ([]map[string]string, error) {
 		items[k] = l[0]
 	}
 
+	if items != nil {
+		groups[group] = items
+	}
+
 	keys := make([]string, 0, len(groups))
 	for k := range groups {
 		keys = append(keys, k)